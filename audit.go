@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// audited entities, every log belongs to a book so it shows in the book history.
+// logs keep the book owner and title so they outlive purged books
+const (
+	AUDIT_BOOK      = "book"
+	AUDIT_HIGHLIGHT = "highlight"
+	AUDIT_COPY      = "copy"
+)
+
+// fields the owner can revert to a previous value from the book history
+var REVERTIBLE_FIELDS = map[string][]string{
+	AUDIT_BOOK:      {"Title", "Subtitle", "Author", "Description", "Publisher", "PageCount", "PageRead", "Rating", "Review", "Tags"},
+	AUDIT_HIGHLIGHT: {"Page", "Content"},
+}
+
+// AuditChange is a field value before and after a change, empty values are
+// null so creating an entity has no before and deleting it has no after
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditSnapshot is the audited fields of an entity by name without the empty
+// ones
+type AuditSnapshot map[string]interface{}
+
+func NewAuditSnapshot(fields map[string]interface{}) AuditSnapshot {
+	s := AuditSnapshot{}
+	for name, value := range fields {
+		switch value {
+		case nil, "", int32(0), false:
+			continue
+		}
+		s[name] = value
+	}
+
+	return s
+}
+
+// AuditDiff returns the fields that differ between two snapshots, a nil
+// snapshot means the entity didn't exist
+func AuditDiff(before, after AuditSnapshot) map[string]AuditChange {
+	changes := map[string]AuditChange{}
+	for name, value := range before {
+		if after[name] != value {
+			changes[name] = AuditChange{Before: value, After: after[name]}
+		}
+	}
+
+	for name, value := range after {
+		if _, ok := before[name]; !ok {
+			changes[name] = AuditChange{After: value}
+		}
+	}
+
+	return changes
+}
+
+// Audit appends a log of the changes, it should use the same transaction as
+// the changes
+func Audit(ctx context.Context, q *Queries, actorID, bookID int64, entity string, entityID int64, action string, changes map[string]AuditChange) error {
+	value, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	return q.NewAuditLog(ctx, NewAuditLogParams{
+		ActorID:  sql.NullInt64{Int64: actorID, Valid: actorID != 0},
+		BookID:   bookID,
+		Entity:   entity,
+		EntityID: entityID,
+		Action:   action,
+		Changes:  value,
+	})
+}
+
+// BookSnapshot reads the book audited fields including trashed books
+func BookSnapshot(ctx context.Context, q *Queries, bookID int64) (AuditSnapshot, error) {
+	book, err := q.BookByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := q.BookTags(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rating interface{}
+	if book.Rating.Valid {
+		rating = book.Rating.Int16
+	}
+
+	return NewAuditSnapshot(map[string]interface{}{
+		"Title":       book.Title,
+		"Subtitle":    book.Subtitle,
+		"Author":      book.Author,
+		"Description": book.Description,
+		"Publisher":   book.Publisher,
+		"PageCount":   book.PageCount,
+		"PageRead":    book.PageRead,
+		"Rating":      rating,
+		"Review":      book.Review,
+		"Tags":        TagsString(tags),
+		"Deleted":     book.DeletedAt.Valid,
+	}), nil
+}
+
+// AuditBook records the changes fn makes to the book, nothing is recorded
+// when the book didn't change
+func AuditBook(ctx context.Context, q *Queries, actorID, bookID int64, action string, fn func() error) error {
+	before, err := BookSnapshot(ctx, q, bookID)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(); err != nil {
+		return err
+	}
+
+	after, err := BookSnapshot(ctx, q, bookID)
+	if err != nil {
+		return err
+	}
+
+	changes := AuditDiff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return Audit(ctx, q, actorID, bookID, AUDIT_BOOK, bookID, action, changes)
+}
+
+// AuditNewBook records the book with all of its fields as created
+func AuditNewBook(ctx context.Context, q *Queries, actorID, bookID int64) error {
+	after, err := BookSnapshot(ctx, q, bookID)
+	if err != nil {
+		return err
+	}
+
+	return Audit(ctx, q, actorID, bookID, AUDIT_BOOK, bookID, "create", AuditDiff(nil, after))
+}
+
+func HighlightSnapshot(h Highlight) AuditSnapshot {
+	return NewAuditSnapshot(map[string]interface{}{
+		"Page":    h.Page,
+		"Content": h.Content,
+		"Deleted": h.DeletedAt.Valid,
+	})
+}
+
+// AuditHighlight records the changes between two versions of the highlight,
+// nil is a highlight that didn't exist
+func AuditHighlight(ctx context.Context, q *Queries, actorID int64, action string, before, after *Highlight) error {
+	var from, to AuditSnapshot
+	h := before
+	if before != nil {
+		from = HighlightSnapshot(*before)
+	}
+	if after != nil {
+		to = HighlightSnapshot(*after)
+		h = after
+	}
+
+	changes := AuditDiff(from, to)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return Audit(ctx, q, actorID, h.BookID, AUDIT_HIGHLIGHT, h.ID, action, changes)
+}
+
+// AuditMove records the shelf fn moves the copy to
+func AuditMove(ctx context.Context, q *Queries, actorID, bookID, copyID int64, fn func() error) error {
+	before, err := q.CopyShelfName(ctx, copyID)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(); err != nil {
+		return err
+	}
+
+	after, err := q.CopyShelfName(ctx, copyID)
+	if err != nil {
+		return err
+	}
+
+	changes := AuditDiff(
+		NewAuditSnapshot(map[string]interface{}{"Shelf": before.String}),
+		NewAuditSnapshot(map[string]interface{}{"Shelf": after.String}),
+	)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return Audit(ctx, q, actorID, bookID, AUDIT_COPY, copyID, "move", changes)
+}
+
+// AuditEntry is an audit log with its changes decoded for display
+type AuditEntry struct {
+	BookAuditLogsRow
+	Diff map[string]AuditChange
+}
+
+// Revertible returns true when the field can be set back to its value before
+// an action on the entity
+func Revertible(entity, action, field string) bool {
+	if action != "update" && action != "revert" {
+		return false
+	}
+
+	for _, f := range REVERTIBLE_FIELDS[entity] {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e AuditEntry) Revertible(field string) bool {
+	return Revertible(e.Entity, e.Action, field)
+}
+
+func AuditEntries(logs []BookAuditLogsRow) ([]AuditEntry, error) {
+	entries := make([]AuditEntry, 0, len(logs))
+	for _, l := range logs {
+		e := AuditEntry{BookAuditLogsRow: l}
+		if err := json.Unmarshal(l.Changes, &e.Diff); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// auditText and auditNumber convert the values decoded from the JSON changes,
+// empty values are nil and numbers are float64
+func auditText(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+func auditNumber(value interface{}) int64 {
+	n, _ := value.(float64)
+	return int64(n)
+}
+
+// RevertBookField sets the book field back to value and records it as a
+// revert
+func RevertBookField(ctx context.Context, q *Queries, actorID int64, book BookByIsbnAndUserRow, field string, value interface{}) (ValidationErrors, error) {
+	review := UpdateBookReviewParams{
+		Rating: book.Rating,
+		Review: book.Review,
+		ID:     book.ID,
+	}
+
+	params := UpdateBookParams{
+		Title:       book.Title,
+		Author:      book.Author,
+		Subtitle:    book.Subtitle,
+		Description: book.Description,
+		Publisher:   book.Publisher,
+		PageCount:   book.PageCount,
+		PageRead:    book.PageRead,
+		ID:          book.ID,
+	}
+
+	switch field {
+	case "Title":
+		params.Title = auditText(value)
+	case "Subtitle":
+		params.Subtitle = auditText(value)
+	case "Author":
+		params.Author = auditText(value)
+	case "Description":
+		params.Description = auditText(value)
+	case "Publisher":
+		params.Publisher = auditText(value)
+	case "PageCount":
+		params.PageCount = int32(auditNumber(value))
+	case "PageRead":
+		params.PageRead = int32(auditNumber(value))
+	case "Rating":
+		review.Rating = sql.NullInt16{Int16: int16(auditNumber(value)), Valid: value != nil}
+	case "Review":
+		review.Review = auditText(value)
+	}
+
+	errors := params.Validate()
+	for k, v := range review.Validate() {
+		errors[k] = v
+	}
+	if field == "Tags" {
+		ValidateTags(auditText(value), "tags", "Tags", errors)
+	}
+	if len(errors) > 0 {
+		return errors, nil
+	}
+
+	return errors, AuditBook(ctx, q, actorID, book.ID, "revert", func() error {
+		switch field {
+		case "Rating", "Review":
+			return q.UpdateBookReview(ctx, review)
+		case "Tags":
+			return SetBookTags(ctx, q, book.UserID, book.ID, auditText(value))
+		}
+
+		if err := q.UpdateBook(ctx, params); err != nil {
+			return err
+		}
+
+		if field != "Author" {
+			return nil
+		}
+
+		return SetBookAuthors(ctx, q, book.UserID, book.ID, params.Author)
+	})
+}
+
+// RevertHighlightField sets the highlight field back to value and records it
+// as a revert
+func RevertHighlightField(ctx context.Context, q *Queries, actorID int64, highlight Highlight, field string, value interface{}) (ValidationErrors, error) {
+	params := UpdateHighlightParams{
+		Page:    highlight.Page,
+		Content: highlight.Content,
+		ID:      highlight.ID,
+	}
+
+	switch field {
+	case "Page":
+		params.Page = int32(auditNumber(value))
+	case "Content":
+		params.Content = auditText(value)
+	}
+
+	errors := params.Validate()
+	if len(errors) > 0 {
+		return errors, nil
+	}
+
+	if err := q.UpdateHighlight(ctx, params); err != nil {
+		return errors, err
+	}
+
+	after := highlight
+	after.Page = params.Page
+	after.Content = params.Content
+
+	return errors, AuditHighlight(ctx, q, actorID, "revert", &highlight, &after)
+}
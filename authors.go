@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var AUTHOR_ROLES = []string{"author", "translator", "editor", "illustrator"}
+
+type AuthorName struct {
+	Name string
+	Role string
+}
+
+var (
+	authorsSeparator = regexp.MustCompile(`\s*(,|;|&|\s+and\s+)\s*`)
+	authorRole       = regexp.MustCompile(`^(.*?)\s*\((\w+)\)$`)
+)
+
+// ParseAuthors splits a free text authors list like "Hal Abelson, Gerald
+// Sussman & Julie Sussman (editor)" into names and roles. A name without a role
+// in parenthesis is an author.
+func ParseAuthors(s string) []AuthorName {
+	names := []AuthorName{}
+	for _, part := range authorsSeparator.Split(s, -1) {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		name := AuthorName{Name: part, Role: "author"}
+		if m := authorRole.FindStringSubmatch(part); m != nil {
+			name.Name = m[1]
+			name.Role = strings.ToLower(m[2])
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// SetBookAuthors replaces the book authors with the ones in authors string and
+// rewrites books.author from the result
+func SetBookAuthors(ctx context.Context, q *Queries, userID, bookID int64, authors string) error {
+	if err := q.DeleteBookAuthors(ctx, bookID); err != nil {
+		return err
+	}
+
+	for i, n := range ParseAuthors(authors) {
+		author, err := q.UpsertAuthor(ctx, UpsertAuthorParams{
+			UserID: userID,
+			Name:   n.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.NewBookAuthor(ctx, NewBookAuthorParams{
+			BookID:   bookID,
+			AuthorID: author.ID,
+			Role:     n.Role,
+			Position: int32(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := q.RefreshBookAuthor(ctx, bookID); err != nil {
+		return err
+	}
+
+	return q.DeleteOrphanAuthors(ctx, userID)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backup dumps the database with pg_dump to dir/YYYY-MM-DD.dump and removes
+// dumps older than keep days
+func Backup(ctx context.Context, dir, keep string) error {
+	if len(dir) == 0 {
+		return fmt.Errorf("BACKUPS_PATH is required")
+	}
+	if len(CONFIG.DatabaseURL) == 0 {
+		return fmt.Errorf("DATABASE_URL is required")
+	}
+
+	days, err := strconv.Atoi(keep)
+	if err != nil || days < 1 {
+		return fmt.Errorf("BACKUPS_LIMIT has to be a number of days, it's %q", keep)
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file := path.Join(dir, time.Now().Format("2006-01-02")+".dump")
+	done := Log(INFO, "Backup", file)
+	dump := exec.CommandContext(ctx, "pg_dump", "-f", file, "-Fc", CONFIG.DatabaseURL)
+	dump.Stdout = os.Stdout
+	dump.Stderr = os.Stderr
+	if err = dump.Run(); err != nil {
+		return fmt.Errorf("pg_dump: %w", err)
+	}
+	done()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	before := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".dump") {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().Before(before) {
+			log.Printf("Removing old backup %s", e.Name())
+			if err = os.Remove(path.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
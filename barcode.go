@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// EAN-13 barcodes are 95 modules wide: a start guard, 6 left digits, a middle
+// guard, 6 right digits and an end guard. Scanning a line across the barcode
+// gives 59 alternating runs of bars and spaces starting with a bar.
+const (
+	EAN13_RUNS         = 59
+	EAN13_MODULES      = 95
+	BARCODE_MAX_SIZE   = 1600
+	BARCODE_SCAN_LINES = 200
+	BARCODE_MIN_HITS   = 2
+	BARCODE_MAX_PIXELS = 50 * 1000 * 1000 // larger than phone camera photos
+)
+
+// widths of the 4 runs of each digit in L code, G code is the same reversed and
+// R code is the same starting with a bar
+var ean13Digits = [10][4]int{
+	{3, 2, 1, 1}, {2, 2, 2, 1}, {2, 1, 2, 2}, {1, 4, 1, 1}, {1, 1, 3, 2},
+	{1, 2, 3, 1}, {1, 1, 1, 4}, {1, 3, 1, 2}, {1, 2, 1, 3}, {3, 1, 1, 2},
+}
+
+// the first digit is encoded in which of the left digits use G code
+var ean13FirstDigit = map[string]byte{
+	"LLLLLL": '0', "LLGLGG": '1', "LLGGLG": '2', "LLGGGL": '3', "LGLLGG": '4',
+	"LGGLLG": '5', "LGGGLL": '6', "LGLGLG": '7', "LGLGGL": '8', "LGGLGL": '9',
+}
+
+// DecodeEAN13 finds the EAN-13 barcodes in an image, it scans rows and columns
+// in both directions so barcodes can be rotated. a barcode has to be read on
+// more than one line to be reported. barcodes are returned in the order they
+// were first seen. the image size is checked before it's decoded so a small
+// file can't claim a huge image.
+func DecodeEAN13(in io.ReadSeeker) ([]string, error) {
+	config, _, err := image.DecodeConfig(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width*config.Height > BARCODE_MAX_PIXELS {
+		return nil, fmt.Errorf("image is %dx%d, it can't be more than %d megapixels", config.Width, config.Height, BARCODE_MAX_PIXELS/1000/1000)
+	}
+
+	if _, err = in.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(in)
+	if err != nil {
+		return nil, err
+	}
+
+	lum := luminance(src)
+	h := len(lum)
+	if h == 0 {
+		return []string{}, nil
+	}
+	w := len(lum[0])
+
+	hits := map[string]int{}
+	codes := []string{}
+	found := func(line []uint8) {
+		for _, code := range scanLine(line) {
+			hits[code]++
+			if hits[code] == BARCODE_MIN_HITS {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	for y := 0; y < h; y += max(1, h/BARCODE_SCAN_LINES) {
+		found(lum[y])
+	}
+
+	column := make([]uint8, h)
+	for x := 0; x < w; x += max(1, w/BARCODE_SCAN_LINES) {
+		for y := range lum {
+			column[y] = lum[y][x]
+		}
+		found(column)
+	}
+
+	return codes, nil
+}
+
+// luminance converts the image to rows of gray levels, large photos are scaled
+// down first
+func luminance(src image.Image) [][]uint8 {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > BARCODE_MAX_SIZE || h > BARCODE_MAX_SIZE {
+		if w > h {
+			w, h = BARCODE_MAX_SIZE, h*BARCODE_MAX_SIZE/w
+		} else {
+			w, h = w*BARCODE_MAX_SIZE/h, BARCODE_MAX_SIZE
+		}
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(gray, gray.Rect, src, b, draw.Src, nil)
+
+	rows := make([][]uint8, h)
+	for y := range rows {
+		rows[y] = gray.Pix[y*gray.Stride : y*gray.Stride+w]
+	}
+
+	return rows
+}
+
+// scanLine reads the barcodes crossed by a line of gray levels in both
+// directions
+func scanLine(line []uint8) []string {
+	runs := binarize(line)
+	codes := decodeRuns(runs)
+
+	reversed := make([]int, len(runs))
+	for i, r := range runs {
+		reversed[len(runs)-1-i] = r
+	}
+
+	// runs start with a space, reversing may make it start with a bar
+	if len(reversed)%2 == 0 {
+		reversed = append([]int{0}, reversed...)
+	}
+
+	return append(codes, decodeRuns(reversed)...)
+}
+
+// binarize thresholds every pixel against the average of its neighbourhood
+// and returns the lengths of the alternating runs starting with a space
+func binarize(line []uint8) []int {
+	n := len(line)
+	sums := make([]int, n+1)
+	for i, v := range line {
+		sums[i+1] = sums[i] + int(v)
+	}
+
+	radius := max(8, n/16)
+	runs := []int{0}
+	dark := false
+	for i, v := range line {
+		from, to := max(0, i-radius), min(n, i+radius+1)
+		mean := (sums[to] - sums[from]) / (to - from)
+
+		isDark := int(v) < mean-4
+		if isDark != dark {
+			runs = append(runs, 0)
+			dark = isDark
+		}
+		runs[len(runs)-1]++
+	}
+
+	return runs
+}
+
+// decodeRuns looks for EAN-13 barcodes in runs starting with a space, every
+// odd index is a bar that can be the start guard
+func decodeRuns(runs []int) []string {
+	codes := []string{}
+	for i := 1; i+EAN13_RUNS <= len(runs); i += 2 {
+		if code, ok := decodeEAN13(runs[i-1], runs[i:i+EAN13_RUNS]); ok {
+			codes = append(codes, code)
+			i += EAN13_RUNS - 1
+		}
+	}
+
+	return codes
+}
+
+func decodeEAN13(quiet int, runs []int) (string, bool) {
+	total := 0
+	for _, r := range runs {
+		total += r
+	}
+	module := float64(total) / EAN13_MODULES
+
+	// the barcode needs a quiet zone before it and single module guards
+	if float64(quiet) < module*3 {
+		return "", false
+	}
+
+	for _, i := range []int{0, 1, 2, 27, 28, 29, 30, 31, 56, 57, 58} {
+		if w := float64(runs[i]) / module; w < 0.4 || w > 1.8 {
+			return "", false
+		}
+	}
+
+	code := make([]byte, 13)
+	parity := make([]byte, 6)
+	for d := 0; d < 12; d++ {
+		start := 3 + d*4
+		if d >= 6 {
+			start += 5
+		}
+
+		digit, g, ok := decodeDigit(runs[start:start+4], d < 6)
+		if !ok {
+			return "", false
+		}
+
+		code[d+1] = '0' + byte(digit)
+		if d < 6 {
+			parity[d] = 'L'
+			if g {
+				parity[d] = 'G'
+			}
+		}
+	}
+
+	first, ok := ean13FirstDigit[string(parity)]
+	if !ok {
+		return "", false
+	}
+	code[0] = first
+
+	if !ean13Checksum(code) {
+		return "", false
+	}
+
+	return string(code), true
+}
+
+// decodeDigit matches the 4 runs of a digit to the nearest digit pattern,
+// left digits can be L or G coded
+func decodeDigit(runs []int, left bool) (digit int, g bool, ok bool) {
+	total := runs[0] + runs[1] + runs[2] + runs[3]
+	best := 1.5
+	for d, p := range ean13Digits {
+		for _, reversed := range []bool{false, true} {
+			if reversed && !left {
+				continue
+			}
+
+			dist := 0.0
+			for i := 0; i < 4; i++ {
+				w := p[i]
+				if reversed {
+					w = p[3-i]
+				}
+
+				diff := float64(runs[i])*7/float64(total) - float64(w)
+				if diff < 0 {
+					diff = -diff
+				}
+				dist += diff
+			}
+
+			if dist < best {
+				best, digit, g, ok = dist, d, reversed, true
+			}
+		}
+	}
+
+	return
+}
+
+func ean13Checksum(code []byte) bool {
+	sum := 0
+	for i, c := range code[:12] {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return (10-sum%10)%10 == int(code[12]-'0')
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ISBNBarcodes keeps the barcodes that are valid ISBN13 numbers, books use the
+// 978 and 979 prefixes
+func ISBNBarcodes(codes []string) []string {
+	isbns := []string{}
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "978") && !strings.HasPrefix(code, "979") {
+			continue
+		}
+
+		ve := ValidationErrors{}
+		ValidateISBN13(code, "isbn", "ISBN", ve)
+		if len(ve) == 0 {
+			isbns = append(isbns, code)
+		}
+	}
+
+	return isbns
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ean13Modules returns the 95 modules of the code, 1 is a bar
+func ean13Modules(code string) string {
+	parity := ""
+	for p, first := range ean13FirstDigit {
+		if first == code[0] {
+			parity = p
+		}
+	}
+
+	runs := func(widths [4]int, bar bool) string {
+		s := ""
+		for _, w := range widths {
+			m := "0"
+			if bar {
+				m = "1"
+			}
+			s += strings.Repeat(m, w)
+			bar = !bar
+		}
+		return s
+	}
+
+	modules := "101"
+	for i, c := range code[1:7] {
+		widths := ean13Digits[c-'0']
+		if parity[i] == 'G' {
+			widths = [4]int{widths[3], widths[2], widths[1], widths[0]}
+		}
+		modules += runs(widths, false)
+	}
+	modules += "01010"
+	for _, c := range code[7:] {
+		modules += runs(ean13Digits[c-'0'], true)
+	}
+
+	return modules + "101"
+}
+
+// barcodeImage draws the code with a quiet zone, each module is 3 pixels
+func barcodeImage(code string) *image.Gray {
+	const module, quiet, height = 3, 12, 60
+	modules := ean13Modules(code)
+	img := image.NewGray(image.Rect(0, 0, (len(modules)+quiet*2)*module, height))
+	for x := 0; x < img.Rect.Dx(); x++ {
+		m := x/module - quiet
+		for y := 0; y < height; y++ {
+			if m >= 0 && m < len(modules) && modules[m] == '1' {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+
+	return img
+}
+
+func transform(src *image.Gray, f func(x, y, w, h int) (int, int), rotate bool) *image.Gray {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	if rotate {
+		dst = image.NewGray(image.Rect(0, 0, h, w))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := f(x, y, w, h)
+			dst.SetGray(dx, dy, src.GrayAt(x, y))
+		}
+	}
+
+	return dst
+}
+
+func encodePNG(t *testing.T, img image.Image) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestDecodeEAN13(t *testing.T) {
+	const isbn = "9780441013593"
+
+	tests := []struct {
+		name     string
+		img      image.Image
+		expected []string
+	}{
+		{"upright", barcodeImage(isbn), []string{isbn}},
+		{"other parity", barcodeImage("4006381333931"), []string{"4006381333931"}},
+		{
+			"rotated 90 degrees",
+			transform(barcodeImage(isbn), func(x, y, w, h int) (int, int) { return h - 1 - y, x }, true),
+			[]string{isbn},
+		},
+		{
+			"upside down",
+			transform(barcodeImage(isbn), func(x, y, w, h int) (int, int) { return w - 1 - x, h - 1 - y }, false),
+			[]string{isbn},
+		},
+		{
+			"flipped",
+			transform(barcodeImage(isbn), func(x, y, w, h int) (int, int) { return w - 1 - x, y }, false),
+			[]string{isbn},
+		},
+		{"bad check digit", barcodeImage("9780441013594"), []string{}},
+		{"blank", image.NewGray(image.Rect(0, 0, 300, 60)), []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			codes, err := DecodeEAN13(encodePNG(t, tc.img))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(codes, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, codes)
+			}
+		})
+	}
+}
+
+func TestDecodeEAN13TooLarge(t *testing.T) {
+	// a GIF header claiming a 65535x65535 image without the pixels
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	if _, err := DecodeEAN13(bytes.NewReader(header)); err == nil {
+		t.Error("expected an error for an image larger than BARCODE_MAX_PIXELS")
+	}
+}
+
+func TestDecodeEAN13NotImage(t *testing.T) {
+	if _, err := DecodeEAN13(strings.NewReader("not an image")); err == nil {
+		t.Error("expected an error for a file that isn't an image")
+	}
+}
+
+func TestISBNBarcodes(t *testing.T) {
+	codes := []string{"4006381333931", "9780441013593", "9780441013594", "9791032305690"}
+	expected := []string{"9780441013593", "9791032305690"}
+
+	if isbns := ISBNBarcodes(codes); !reflect.DeepEqual(isbns, expected) {
+		t.Errorf("expected %v, got %v", expected, isbns)
+	}
+}
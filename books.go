@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// AddBook creates the book with its work, authors, tags and one copy on the
+// shelf and records it in the book history
+func AddBook(ctx context.Context, q *Queries, params NewBookParams, tags string, shelfID sql.NullInt64) (Book, error) {
+	work, err := q.NewWork(ctx, NewWorkParams{
+		UserID: params.UserID,
+		Title:  params.Title,
+	})
+	if err != nil {
+		return Book{}, err
+	}
+
+	params.WorkID = work.ID
+	book, err := q.NewBook(ctx, params)
+	if err != nil {
+		return book, err
+	}
+
+	if _, err = q.NewCopy(ctx, NewCopyParams{BookID: book.ID, ShelfID: shelfID}); err != nil {
+		return book, err
+	}
+
+	if err = SetBookAuthors(ctx, q, params.UserID, book.ID, params.Author); err != nil {
+		return book, err
+	}
+
+	if err = SetBookTags(ctx, q, params.UserID, book.ID, tags); err != nil {
+		return book, err
+	}
+
+	return book, AuditNewBook(ctx, q, params.UserID, book.ID)
+}
+
+// BULK_ADD_LIMIT is the most ISBNs added at once, they are looked up and added
+// in the background. the metadata of each scanned book takes a turn of every
+// provider and providers are asked once every METADATA_RATE, scanned books that
+// aren't looked up in BULK_ADD_TIMEOUT fail. BULK_ADD_CONCURRENCY lookups run at
+// once so each provider has one waiting for its turn
+const (
+	BULK_ADD_LIMIT       = 500
+	BULK_ADD_TIMEOUT     = 10 * time.Second
+	BULK_ADD_CONCURRENCY = 2
+)
+
+var bulkAdding sync.Map
+
+// BulkBook is a book to add by its ISBN with the metadata it would be added
+// with, books the user already has or with errors are skipped
+type BulkBook struct {
+	Metadata BookMetadata
+	Exists   bool
+	Errors   ValidationErrors
+}
+
+// ParseISBNList extracts the ISBNs from a list separated by spaces, new lines,
+// commas or semicolons. dashes are removed, ISBN10 numbers are converted to
+// ISBN13 and duplicates are dropped
+func ParseISBNList(list string) []string {
+	fields := strings.FieldsFunc(list, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+
+	seen := map[string]bool{}
+	isbns := []string{}
+	for _, f := range fields {
+		isbn := ISBN10To13(strings.ToUpper(strings.ReplaceAll(f, "-", "")))
+		if !seen[isbn] {
+			seen[isbn] = true
+			isbns = append(isbns, isbn)
+		}
+	}
+
+	return isbns
+}
+
+// ISBN10To13 converts a valid ISBN10 to ISBN13, anything else is returned as is
+func ISBN10To13(isbn string) string {
+	if len(isbn) != 10 {
+		return isbn
+	}
+
+	sum := 0
+	for i, c := range isbn {
+		d := int(c - '0')
+		if i == 9 && c == 'X' {
+			d = 10
+		} else if c < '0' || c > '9' {
+			return isbn
+		}
+		sum += (10 - i) * d
+	}
+
+	if sum%11 != 0 {
+		return isbn
+	}
+
+	isbn = "978" + isbn[:9]
+	sum = 0
+	for i, c := range isbn {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return fmt.Sprintf("%s%d", isbn, (10-sum%10)%10)
+}
+
+// LookupBooks validates the ISBNs, checks which books the user already has and
+// looks up the metadata of the rest concurrently. lookups that don't finish in
+// BULK_ADD_TIMEOUT fail so the user can try them again
+func LookupBooks(ctx context.Context, userID int64, isbns []string) ([]BulkBook, error) {
+	books := make([]BulkBook, len(isbns))
+	lookupCtx, cancel := context.WithTimeout(ctx, BULK_ADD_TIMEOUT)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	running := make(chan struct{}, BULK_ADD_CONCURRENCY)
+	for i, isbn := range isbns {
+		book := &books[i]
+		book.Metadata.Isbn = isbn
+		book.Errors = ValidationErrors{}
+
+		ValidateISBN13(isbn, "isbn", "ISBN", book.Errors)
+		if len(book.Errors) > 0 {
+			continue
+		}
+
+		_, err := Q.BookByIsbnAndUser(ctx, BookByIsbnAndUserParams{
+			UserID: userID,
+			Isbn:   isbn,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if err == nil {
+			book.Exists = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(isbn string) {
+			defer wg.Done()
+
+			select {
+			case running <- struct{}{}:
+				defer func() { <-running }()
+			case <-lookupCtx.Done():
+				book.Errors.Add("isbn", fmt.Errorf("Looking up the book took too long, try adding it again"))
+				return
+			}
+
+			book.Metadata = MergeMetadata(LookupMetadata(lookupCtx, Q, isbn), isbn)
+			if lookupCtx.Err() != nil {
+				book.Errors.Add("isbn", fmt.Errorf("Looking up the book took too long, try adding it again"))
+				return
+			}
+
+			params := book.Metadata.NewBookParams()
+			params.UserID = userID
+			book.Errors = params.Validate()
+		}(isbn)
+	}
+	wg.Wait()
+
+	return books, nil
+}
+
+// StartBulkAdd saves the ISBNs to add to the shelf and adds them in the
+// background, invalid ISBNs are saved as failed
+func StartBulkAdd(ctx context.Context, userID int64, isbns []string, shelfID sql.NullInt64) (BulkAdd, error) {
+	var job BulkAdd
+	err := Transaction(ctx, func(q *Queries) error {
+		var err error
+		job, err = q.NewBulkAdd(ctx, NewBulkAddParams{
+			UserID:  userID,
+			ShelfID: shelfID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, isbn := range isbns {
+			errors := ValidationErrors{}
+			ValidateISBN13(isbn, "isbn", "ISBN", errors)
+
+			status := "waiting"
+			if len(errors) > 0 {
+				status = "failed"
+			}
+
+			err = q.NewBulkAddBook(ctx, NewBulkAddBookParams{
+				BulkAddID: job.ID,
+				Isbn:      isbn,
+				Status:    status,
+				Error:     errors.Join(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return job, err
+	}
+
+	go RunBulkAdd(job)
+	return job, nil
+}
+
+// RunBulkAdd looks up and adds the waiting books of the bulk add one after the
+// other. each book result is saved so the job continues where it stopped after
+// a restart
+func RunBulkAdd(job BulkAdd) {
+	if _, running := bulkAdding.LoadOrStore(job.ID, true); running {
+		return
+	}
+	defer bulkAdding.Delete(job.ID)
+
+	ctx := context.Background()
+	for {
+		next, err := Q.NextBulkAddBook(ctx, job.ID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			log.Printf("Bulk add %d stopped: %s", job.ID, err)
+			return
+		}
+
+		status, title, message, err := addBulkBook(ctx, job, next.Isbn)
+		if err != nil {
+			log.Printf("Bulk add %d failed for %s: %s", job.ID, next.Isbn, err)
+			status, message = "failed", "Adding the book failed, try adding it again"
+		}
+
+		err = Q.UpdateBulkAddBook(ctx, UpdateBulkAddBookParams{
+			Status: status,
+			Title:  title,
+			Error:  message,
+			ID:     next.ID,
+		})
+		if err != nil {
+			log.Printf("Bulk add %d stopped: %s", job.ID, err)
+			return
+		}
+	}
+
+	if err := Q.FinishBulkAdd(ctx, job.ID); err != nil {
+		log.Printf("Bulk add %d can't be finished: %s", job.ID, err)
+	}
+}
+
+// addBulkBook adds the book with a copy on the job shelf unless the user has
+// it already and returns its status, title and why it failed
+func addBulkBook(ctx context.Context, job BulkAdd, isbn string) (status, title, message string, err error) {
+	book, err := Q.BookByIsbnAndUser(ctx, BookByIsbnAndUserParams{
+		UserID: job.UserID,
+		Isbn:   isbn,
+	})
+	if err == nil {
+		return "exists", book.Title, "", nil
+	}
+	if err != sql.ErrNoRows {
+		return "", "", "", err
+	}
+
+	m := MergeMetadata(LookupMetadata(ctx, Q, isbn), isbn)
+	params := m.NewBookParams()
+	params.UserID = job.UserID
+	if errors := params.Validate(); len(errors) > 0 {
+		return "failed", m.Title, errors.Join(), nil
+	}
+
+	err = Transaction(ctx, func(q *Queries) error {
+		_, err := AddBook(ctx, q, params, "", job.ShelfID)
+		return err
+	})
+	if IsUniqueViolation(err) {
+		return "exists", m.Title, "", nil
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return "added", m.Title, "", nil
+}
+
+// ResumeBulkAdds continues the bulk adds that were running when the server
+// stopped
+func ResumeBulkAdds() {
+	jobs, err := Q.RunningBulkAdds(context.Background())
+	if err != nil {
+		log.Printf("Can't resume bulk adds: %s", err)
+		return
+	}
+
+	for _, job := range jobs {
+		go RunBulkAdd(job)
+	}
+}
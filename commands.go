@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Command is a subcommand of the binary like "migrate" or "user list", the
+// server runs with "serve" or without a command
+type Command struct {
+	Name     string // one or more words
+	Args     string // arguments and flags after the name for the usage line
+	Help     string
+	Database bool // connect to the database before running
+	Run      func(ctx context.Context, args []string) error
+}
+
+// ErrUsage is returned by commands when the arguments are wrong, the usage line
+// is added to it
+var ErrUsage = errors.New("Invalid arguments")
+
+var commands = []Command{}
+
+// COMMAND adds a command, commands are listed in the order they're added
+func COMMAND(c Command) {
+	for _, v := range commands {
+		if v.Name == c.Name {
+			log.Fatalf("Command: %s has been defined already", c.Name)
+		}
+	}
+
+	commands = append(commands, c)
+}
+
+func (c Command) Usage() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", APP_NAME, c.Name, c.Args))
+}
+
+func PrintCommands(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.Name, c.Args, c.Help)
+	}
+	tw.Flush()
+}
+
+// FindCommand returns the command with the longest name args start with and
+// the arguments after the name, no arguments is serve
+func FindCommand(args []string) (Command, []string, bool) {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	var found Command
+	n := 0
+	for _, c := range commands {
+		words := strings.Fields(c.Name)
+		if len(words) <= n || len(words) > len(args) {
+			continue
+		}
+
+		match := true
+		for i, w := range words {
+			match = match && args[i] == w
+		}
+
+		if match {
+			found, n = c, len(words)
+		}
+	}
+
+	return found, args[n:], n > 0
+}
+
+func RunCommand(ctx context.Context, args []string) error {
+	c, args, ok := FindCommand(args)
+	if !ok {
+		return fmt.Errorf("Unknown command %s, %s help lists the commands", strings.Join(args, " "), APP_NAME)
+	}
+
+	for _, a := range args {
+		if a == "-h" || a == "-help" || a == "--help" {
+			fmt.Printf("Usage: %s\n\n%s\n", c.Usage(), c.Help)
+			return nil
+		}
+	}
+
+	if c.Database {
+		if err := Connect(); err != nil {
+			return err
+		}
+	}
+
+	err := c.Run(ctx, args)
+	if errors.Is(err, ErrUsage) {
+		return fmt.Errorf("%w, usage: %s", err, c.Usage())
+	}
+
+	return err
+}
+
+// parseFlags parses flags before, between or after the arguments like
+// "goodreads.csv --user=slug" and returns the arguments
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	rest := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUsage, err)
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// userFlag adds the --user flag and returns the user after the flags are parsed
+func userFlag(flags *flag.FlagSet) func(context.Context) (User, error) {
+	slug := flags.String("user", "", "user slug")
+	return func(ctx context.Context) (User, error) {
+		if len(*slug) == 0 {
+			return User{}, fmt.Errorf("%w: --user is required", ErrUsage)
+		}
+
+		user, err := Q.UserBySlug(ctx, *slug)
+		if err != nil {
+			return user, fmt.Errorf("User %s: %w", *slug, err)
+		}
+
+		return user, nil
+	}
+}
+
+func Commands() {
+	COMMAND(Command{
+		Name:     "serve",
+		Help:     "Serve HTTP, the default when no command is given",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			return Serve(ctx, args)
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "migrate",
+		Help:     "Apply the pending migrations",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			return Migrate(ctx, DB.DB)
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "migrate rollback",
+		Help:     "Revert the last applied migration",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			return Rollback(ctx, DB.DB)
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "migrate status",
+		Help:     "List the migrations and whether they're applied",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			return MigrationStatus(ctx, DB.DB)
+		},
+	})
+
+	COMMAND(Command{
+		Name: "migrate create",
+		Args: "<name>",
+		Help: "Add an empty migration to " + MIGRATIONS_DIR,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+
+			p, err := CreateMigration(args[0])
+			if err != nil {
+				return err
+			}
+
+			log.Printf("Migration created: %s", p)
+			return nil
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "user list",
+		Help:     "List the users with their books count",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			users, err := Q.Users(ctx)
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSLUG\tNAME\tEMAIL\tADMIN\tBOOKS")
+			for _, u := range users {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%d\n", u.ID, u.Slug, u.Name.String, u.Email.String, u.Admin, u.BooksCount)
+			}
+
+			return tw.Flush()
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "user promote",
+		Args:     "<slug>",
+		Help:     "Make the user an admin",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+
+			user, err := Q.UserBySlug(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("User %s: %w", args[0], err)
+			}
+
+			if err = Q.PromoteUser(ctx, user.ID); err != nil {
+				return err
+			}
+
+			log.Printf("User %s is an admin", user.Slug)
+			return nil
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "user delete",
+		Args:     "<slug> [--yes]",
+		Help:     "Delete the user with their books, shelves, highlights, notes and wishes",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			flags := flag.NewFlagSet("user delete", flag.ContinueOnError)
+			yes := flags.Bool("yes", false, "confirm deleting")
+			args, err := parseFlags(flags, args)
+			if err != nil {
+				return err
+			}
+			if len(args) != 1 {
+				return ErrUsage
+			}
+
+			user, err := Q.UserBySlug(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("User %s: %w", args[0], err)
+			}
+
+			if !*yes {
+				count, err := Q.BooksCount(ctx, user.ID)
+				if err != nil {
+					return err
+				}
+
+				return fmt.Errorf("Deleting %s removes their %d books and everything they added, run it again with --yes to delete", user.Slug, count)
+			}
+
+			if err = Q.DeleteUser(ctx, user.ID); err != nil {
+				return err
+			}
+			log.Printf("User %s deleted", user.Slug)
+
+			removed, err := RemoveUnusedImages(ctx)
+			log.Printf("Removed %d unused images", len(removed))
+			return err
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "import goodreads",
+		Args:     "<file> --user=<slug>",
+		Help:     "Add the books in a Goodreads library export CSV to the user library",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			flags := flag.NewFlagSet("import goodreads", flag.ContinueOnError)
+			userBySlug := userFlag(flags)
+			args, err := parseFlags(flags, args)
+			if err != nil {
+				return err
+			}
+			if len(args) != 1 {
+				return ErrUsage
+			}
+
+			user, err := userBySlug(ctx)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			result, err := ImportGoodreads(ctx, f, user)
+			for _, s := range result.Skipped {
+				log.Printf("Skipped %s", s)
+			}
+			log.Printf("Imported %d books, skipped %d", result.Imported, len(result.Skipped))
+
+			return err
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "export",
+		Args:     "--user=<slug>",
+		Help:     "Write the user books with their shelves, tags, reviews, highlights and notes as JSON to stdout",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			flags := flag.NewFlagSet("export", flag.ContinueOnError)
+			userBySlug := userFlag(flags)
+			args, err := parseFlags(flags, args)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			user, err := userBySlug(ctx)
+			if err != nil {
+				return err
+			}
+
+			return ExportUser(ctx, os.Stdout, user)
+		},
+	})
+
+	COMMAND(Command{
+		Name:     "images gc",
+		Args:     "[--dry-run]",
+		Help:     "Remove uploaded images and cached Google covers nothing uses",
+		Database: true,
+		Run: func(ctx context.Context, args []string) error {
+			flags := flag.NewFlagSet("images gc", flag.ContinueOnError)
+			dryRun := flags.Bool("dry-run", false, "list the images without removing them")
+			args, err := parseFlags(flags, args)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			if *dryRun {
+				unused, err := UnusedImages(ctx)
+				for _, p := range unused {
+					fmt.Println(p)
+				}
+				return err
+			}
+
+			removed, err := RemoveUnusedImages(ctx)
+			for _, p := range removed {
+				fmt.Println(p)
+			}
+			log.Printf("Removed %d unused images", len(removed))
+
+			return err
+		},
+	})
+
+	COMMAND(Command{
+		Name: "backup",
+		Help: "Dump the database to BACKUPS_PATH and remove dumps older than BACKUPS_LIMIT days",
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			return Backup(ctx, os.Getenv("BACKUPS_PATH"), os.Getenv("BACKUPS_LIMIT"))
+		},
+	})
+
+	COMMAND(Command{
+		Name: "help",
+		Help: "List the commands",
+		Run: func(ctx context.Context, args []string) error {
+			fmt.Printf("Usage: %s [flags] [command]\n\nCommands:\n", APP_NAME)
+			PrintCommands(os.Stdout)
+			fmt.Printf("\n%s -h lists the flags\n", APP_NAME)
+			return nil
+		},
+	})
+}
@@ -14,14 +14,18 @@ package main
 // 1. Copy common.go, .env.sample, sqlc.yaml
 // 2. Write queries in query.sql and use `go generate` to generate functions with sqlc
 // 3. Use `router` to add your gorilla routes, or shorthand methods GET, POST, DELETE...etc
+//    name routes with .Name() to build their paths with url_for
 // 4. Add Helpers to `helpers` map
-// 5. call `Start()` to start the server
+// 5. call `Connect()` then `Start()` to start the server
 
 import (
 	"bytes"
 	"context"
 	"database/sql"
 	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
 	"image"
@@ -31,33 +35,38 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"regexp"
+	"os/signal"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template/parse"
 	"time"
 
 	_ "embed"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/sessions"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const (
-	APP_NAME                = "library"
-	MAX_DB_OPEN_CONNECTIONS = 5
-	MAX_DB_IDLE_CONNECTIONS = 5
-	STATIC_DIR_PATH         = "public"
-	BIND_ADDRESS            = "0.0.0.0:3000"
-	VIEWS_EXTENSION         = ".html"
-	SESSION_COOKIE_NAME     = APP_NAME + "_session"
-	CSRF_COOKIE_NAME        = APP_NAME + "_csrf"
+	APP_NAME            = "library"
+	VIEWS_EXTENSION     = ".html"
+	SESSION_COOKIE_NAME = APP_NAME + "_session"
+	CSRF_COOKIE_NAME    = APP_NAME + "_csrf"
 )
 
 var (
+	DB      *sqlx.DB
 	Q       *Queries
 	router  *Handler = &Handler{}
 	session *sessions.CookieStore
@@ -80,36 +89,206 @@ const (
 	PB
 )
 
-func init() {
-	log.SetFlags(log.Ltime)
+var (
+	connectOnce sync.Once
+	connectErr  error
+)
 
-	db, err := sqlx.Connect("postgres", os.Getenv("DATABASE_URL"))
-	if err != nil {
-		log.Fatal(err)
+// Connect opens the database connection pool and sets DB and Q the first time
+// it's called, so commands that don't use the database work without it
+func Connect() error {
+	connectOnce.Do(func() {
+		if len(CONFIG.DatabaseURL) == 0 {
+			connectErr = fmt.Errorf("DATABASE_URL is required")
+			return
+		}
+
+		DB, connectErr = sqlx.Connect("postgres", CONFIG.DatabaseURL)
+		if connectErr != nil {
+			return
+		}
+
+		DB.SetMaxOpenConns(CONFIG.MaxDBOpenConnections)
+		DB.SetMaxIdleConns(CONFIG.MaxDBIdleConnections)
+		Q = New(queryLogger{DB})
+	})
+
+	return connectErr
+}
+
+// CONFIG ================================================
+
+type Config struct {
+	BindAddress          string
+	ReadTimeout          time.Duration
+	WriteTimeout         time.Duration
+	ShutdownTimeout      time.Duration // how long to wait for requests to finish when stopping
+	MaxDBOpenConnections int
+	MaxDBIdleConnections int
+	StaticDirPath        string
+	LogFormat            string
+	AutoMigrate          bool     // apply pending migrations before serving
+	Args                 []string // the command and its arguments after the flags
+	DatabaseURL          string   // secrets are only read from the environment
+	SessionSecret        string
+}
+
+// CONFIG has the defaults until LoadConfig overrides them
+var CONFIG = Config{
+	BindAddress:          "0.0.0.0:3000",
+	ReadTimeout:          15 * time.Second,
+	WriteTimeout:         15 * time.Second,
+	ShutdownTimeout:      25 * time.Second,
+	MaxDBOpenConnections: 5,
+	MaxDBIdleConnections: 5,
+	StaticDirPath:        "public",
+	LogFormat:            "human",
+}
+
+// LoadConfig reads the settings from the environment then the flags in args,
+// flags win over the environment. it returns all the invalid settings at once
+func LoadConfig(args []string) error {
+	c := CONFIG
+	errs := []string{}
+	env := func(name string, set func(string) error) {
+		if v, ok := os.LookupEnv(name); ok && len(v) > 0 {
+			if err := set(v); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+	}
+	duration := func(d *time.Duration) func(string) error {
+		return func(v string) (err error) {
+			*d, err = time.ParseDuration(v)
+			return
+		}
+	}
+	number := func(i *int) func(string) error {
+		return func(v string) (err error) {
+			*i, err = strconv.Atoi(v)
+			return
+		}
+	}
+	text := func(s *string) func(string) error {
+		return func(v string) error {
+			*s = v
+			return nil
+		}
 	}
 
-	db.SetMaxOpenConns(MAX_DB_OPEN_CONNECTIONS)
-	db.SetMaxIdleConns(MAX_DB_IDLE_CONNECTIONS)
+	env("BIND_ADDRESS", text(&c.BindAddress))
+	env("READ_TIMEOUT", duration(&c.ReadTimeout))
+	env("WRITE_TIMEOUT", duration(&c.WriteTimeout))
+	env("SHUTDOWN_TIMEOUT", duration(&c.ShutdownTimeout))
+	env("MAX_DB_OPEN_CONNECTIONS", number(&c.MaxDBOpenConnections))
+	env("MAX_DB_IDLE_CONNECTIONS", number(&c.MaxDBIdleConnections))
+	env("STATIC_DIR_PATH", text(&c.StaticDirPath))
+	env("LOG_FORMAT", text(&c.LogFormat))
+	env("AUTO_MIGRATE", func(v string) (err error) {
+		c.AutoMigrate, err = strconv.ParseBool(v)
+		return
+	})
+	env("DATABASE_URL", text(&c.DatabaseURL))
+	env("SESSION_SECRET", text(&c.SessionSecret))
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid configuration: %s", strings.Join(errs, ", "))
+	}
 
-	Q = New(queryLogger{db})
-	session = sessions.NewCookieStore([]byte(os.Getenv("SESSION_SECRET")))
-	session.Options.HttpOnly = true
+	flags := flag.NewFlagSet(APP_NAME, flag.ContinueOnError)
+	flags.StringVar(&c.BindAddress, "bind", c.BindAddress, "address to listen on (BIND_ADDRESS)")
+	flags.DurationVar(&c.ReadTimeout, "read-timeout", c.ReadTimeout, "maximum duration to read a request (READ_TIMEOUT)")
+	flags.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "maximum duration to write a response (WRITE_TIMEOUT)")
+	flags.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "time to wait for requests to finish when stopping (SHUTDOWN_TIMEOUT)")
+	flags.IntVar(&c.MaxDBOpenConnections, "db-max-open", c.MaxDBOpenConnections, "maximum open database connections (MAX_DB_OPEN_CONNECTIONS)")
+	flags.IntVar(&c.MaxDBIdleConnections, "db-max-idle", c.MaxDBIdleConnections, "maximum idle database connections (MAX_DB_IDLE_CONNECTIONS)")
+	flags.StringVar(&c.StaticDirPath, "static", c.StaticDirPath, "directory of the static files (STATIC_DIR_PATH)")
+	flags.StringVar(&c.LogFormat, "log-format", c.LogFormat, "human or json (LOG_FORMAT)")
+	flags.BoolVar(&c.AutoMigrate, "migrate", c.AutoMigrate, "apply pending migrations before serving (AUTO_MIGRATE)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] [command]\n\nFlags:\n", APP_NAME)
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nCommands:\n")
+		PrintCommands(flags.Output())
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	c.Args = flags.Args()
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	CONFIG = c
+	return nil
+}
+
+func (c Config) Validate() error {
+	errs := []string{}
+	if _, _, err := net.SplitHostPort(c.BindAddress); err != nil {
+		errs = append(errs, fmt.Sprintf("bind address %s: %s", c.BindAddress, err))
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 {
+		errs = append(errs, "read and write timeouts have to be positive")
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, "shutdown timeout can't be negative")
+	}
+	if c.MaxDBOpenConnections < 1 {
+		errs = append(errs, "maximum open database connections has to be at least 1")
+	}
+	if c.MaxDBIdleConnections < 0 || c.MaxDBIdleConnections > c.MaxDBOpenConnections {
+		errs = append(errs, "maximum idle database connections has to be between 0 and the maximum open connections")
+	}
+	if stat, err := os.Stat(c.StaticDirPath); err != nil || !stat.IsDir() {
+		errs = append(errs, fmt.Sprintf("static directory %s doesn't exist", c.StaticDirPath))
+	}
+	if c.LogFormat != "human" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Sprintf("log format %s isn't supported, use human or json", c.LogFormat))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid configuration: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
 }
 
 func Start() {
+	if len(CONFIG.SessionSecret) == 0 {
+		log.Fatal("SESSION_SECRET is required")
+	}
+	session = sessions.NewCookieStore([]byte(CONFIG.SessionSecret))
+	session.Options.HttpOnly = true
+
+	if CONFIG.AutoMigrate {
+		if err := Migrate(context.Background(), DB.DB); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	compileViews()
 	middlewares := []func(http.Handler) http.Handler{
 		methodOverrideHandler,
 		csrf.Protect(
-			[]byte(os.Getenv("SESSION_SECRET")),
+			[]byte(CONFIG.SessionSecret),
 			csrf.Path("/"),
 			csrf.FieldName("csrf"),
 			csrf.CookieName(CSRF_COOKIE_NAME),
+			csrf.ErrorHandler(RenderError(http.StatusForbidden, "The form has expired, please reload the page and try again.")),
 		),
+		recoverHandler,
 		RequestLoggerHandler,
+		requestIDHandler,
 	}
 
-	ROUTE(staticWithoutDirectoryListingHandler())
+	GET("/metrics", MetricsHandler).Name("metrics")
+	GET("/healthz", HealthzHandler).Name("healthz")
+	GET("/readyz", ReadyzHandler).Name("readyz")
+	GET("/version", VersionHandler).Name("version")
+
+	// static files are matched after all routes
+	ROUTE(http.MethodGet, checkStaticFile(http.Dir(CONFIG.StaticDirPath)), staticWithoutDirectoryListingHandler()).name = "static"
 
 	var handler http.Handler = router
 	for _, v := range middlewares {
@@ -118,67 +297,247 @@ func Start() {
 
 	srv := &http.Server{
 		Handler:      handler,
-		Addr:         BIND_ADDRESS,
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+		Addr:         CONFIG.BindAddress,
+		WriteTimeout: CONFIG.WriteTimeout,
+		ReadTimeout:  CONFIG.ReadTimeout,
 	}
 
-	log.Printf("Starting server: %s", BIND_ADDRESS)
-	log.Fatal(srv.ListenAndServe())
+	go func() {
+		log.Printf("Starting server: %s", CONFIG.BindAddress)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// stop accepting connections on SIGTERM or interrupt and wait for the
+	// requests in flight, like uploads, before closing the database
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	<-stop
+
+	log.Printf("Shutting down, waiting up to %s for requests to finish", CONFIG.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), CONFIG.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %s", err)
+	}
+
+	DB.Close()
 }
 
 // Mux/Handler ===========================================
+
+// Routes with a path pattern are stored in a tree of path segments where a
+// {placeholder} segment matches any non empty segment. the escaped path is
+// matched so values can have slashes that url_for escaped. when many routes match
+// a request the first registered wins. routes added with a RouteCheck are
+// tried in order after the tree, like the static files.
 type RouteCheck func(Request) (Request, bool)
 
 type Route struct {
-	checks []RouteCheck
+	method string     // GET routes handle HEAD requests too
+	path   string     // the pattern the route was added with
+	name   string     // the name of the route path, used in the logs
+	params []string   // names of the path placeholders in order
+	check  RouteCheck // routes outside the tree match the request with it
 	route  http.HandlerFunc
+	index  int // registration order
+}
+
+type node struct {
+	static map[string]*node
+	param  *node
+	routes []*Route
 }
 
 type Handler struct {
-	routes []Route
+	tree   node
+	checks []*Route
+	count  int
+	names  map[string]*Route // named routes to build paths with url_for
 }
 
-func (h *Handler) ServeHTTP(w Response, r Request) {
-ROUTES:
-	for _, route := range h.routes {
-		rn := r
-		ok := false
-		for _, check := range route.checks {
-			if rn, ok = check(rn); !ok {
-				continue ROUTES
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func (h *Handler) add(method, path string, route http.HandlerFunc) *Route {
+	r := &Route{method: method, path: path, route: route, index: h.count}
+	h.count++
+
+	for name, named := range h.names {
+		if named.path == path {
+			r.name = name
+		}
+	}
+
+	n := &h.tree
+	for _, segment := range splitPath(path) {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			r.params = append(r.params, segment[1:len(segment)-1])
+			if n.param == nil {
+				n.param = &node{}
 			}
+			n = n.param
+			continue
+		}
+
+		if strings.ContainsAny(segment, "{}") {
+			log.Fatalf("Route %s: placeholders have to be whole path segments", path)
+		}
+
+		if n.static == nil {
+			n.static = map[string]*node{}
 		}
+		if n.static[segment] == nil {
+			n.static[segment] = &node{}
+		}
+		n = n.static[segment]
+	}
+
+	n.routes = append(n.routes, r)
+	return r
+}
+
+// Name registers the route path with a name so url_for can build it, routes
+// with the same path share one name
+func (route *Route) Name(name string) *Route {
+	if router.names == nil {
+		router.names = map[string]*Route{}
+	}
+
+	if _, ok := router.names[name]; ok {
+		log.Fatalf("Route: %s has been named already", name)
+	}
+
+	route.name = name
+	router.names[name] = route
+	return route
+}
+
+// url_for builds the path of the named route replacing its placeholders with
+// params in order. it panics when the route doesn't exist or params don't
+// match the placeholders, templates return it as an error
+func url_for(name string, params ...interface{}) string {
+	route, ok := router.names[name]
+	if !ok {
+		panic(fmt.Sprintf("url_for: route %s doesn't exist", name))
+	}
+
+	if len(params) != len(route.params) {
+		panic(fmt.Sprintf("url_for: route %s expects %d params, got %d", name, len(route.params), len(params)))
+	}
+
+	segments := splitPath(route.path)
+	i := 0
+	for j, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[j] = url.PathEscape(fmt.Sprint(params[i]))
+			i++
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
 
-		route.route(w, rn)
+// match calls found with every route matching the path segments and the
+// values of its placeholders
+func (n *node) match(segments, values []string, found func(*Route, []string)) {
+	if len(segments) == 0 {
+		for _, r := range n.routes {
+			found(r, values)
+		}
 		return
 	}
+
+	if child, ok := n.static[segments[0]]; ok {
+		child.match(segments[1:], values, found)
+	}
+
+	if n.param != nil && len(segments[0]) > 0 {
+		n.param.match(segments[1:], append(values, segments[0]), found)
+	}
 }
 
-func checkMethod(method string) RouteCheck {
-	return func(r Request) (Request, bool) { return r, r.Method == method }
+// ServeHTTP runs the first route that matches the request. when the path
+// matches routes of other methods it responds with 405 and the allowed
+// methods, otherwise with 404
+func (h *Handler) ServeHTTP(w Response, r Request) {
+	var match *Route
+	var matchValues []string
+	h.tree.match(splitPath(r.URL.EscapedPath()), make([]string, 0, 8), func(route *Route, values []string) {
+		if route.allows(r.Method) && (match == nil || route.index < match.index) {
+			match = route
+			matchValues = append(matchValues[:0], values...)
+		}
+	})
+
+	if match != nil {
+		if l, ok := r.Context().Value("log").(*requestLog); ok {
+			l.route = match.name
+		}
+
+		vars := make(map[string]string, len(match.params))
+		for i, name := range match.params {
+			vars[name], _ = url.PathUnescape(matchValues[i])
+		}
+
+		match.route(w, r.WithContext(context.WithValue(r.Context(), "vars", vars)))
+		return
+	}
+
+	for _, route := range h.checks {
+		if !route.allows(r.Method) {
+			continue
+		}
+
+		if rn, ok := route.check(r); ok {
+			if l, ok := r.Context().Value("log").(*requestLog); ok {
+				l.route = route.name
+			}
+
+			route.route(w, rn)
+			return
+		}
+	}
+
+	if allow := h.allowed(r); len(allow) > 0 {
+		MethodNotAllowed(allow)(w, r)
+		return
+	}
+
+	NotFound(w, r)
 }
 
-func checkPath(path string) RouteCheck {
-	placeholder := regexp.MustCompile("{([^}]*)}")
-	path = "^" + placeholder.ReplaceAllString(path, "(?P<$1>[^/]+)") + "$"
-	reg := regexp.MustCompile(path)
-	groups := reg.SubexpNames()
+func (route *Route) allows(method string) bool {
+	return route.method == method || (route.method == http.MethodGet && method == http.MethodHead)
+}
 
-	return func(r Request) (Request, bool) {
-		if !reg.MatchString(r.URL.Path) {
-			return r, false
+// allowed lists the methods of the routes matching the request path
+func (h *Handler) allowed(r Request) []string {
+	allow := []string{}
+	seen := map[string]bool{}
+	add := func(route *Route, _ []string) {
+		if !seen[route.method] {
+			seen[route.method] = true
+			allow = append(allow, route.method)
 		}
 
-		values := reg.FindStringSubmatch(r.URL.Path)
-		vars := map[string]string{}
-		for i, g := range groups {
-			vars[g] = values[i]
+		if route.method == http.MethodGet && !seen[http.MethodHead] {
+			seen[http.MethodHead] = true
+			allow = append(allow, http.MethodHead)
 		}
+	}
 
-		ctx := context.WithValue(r.Context(), "vars", vars)
-		return r.WithContext(ctx), true
+	h.tree.match(splitPath(r.URL.EscapedPath()), nil, add)
+	for _, route := range h.checks {
+		if _, ok := route.check(r); ok {
+			add(route, nil)
+		}
 	}
+
+	return allow
 }
 
 func VARS(r Request) map[string]string {
@@ -191,43 +550,185 @@ func VARS(r Request) map[string]string {
 // LOGGING ===============================================
 
 const (
-	DEBUG = "\033[97;42m"
-	INFO  = "\033[97;43m"
+	DEBUG = "debug"
+	INFO  = "info"
+	ERROR = "error"
 )
 
+var LOG_COLORS = map[string]string{
+	DEBUG: "\033[97;42m",
+	INFO:  "\033[97;43m",
+	ERROR: "\033[97;41m",
+}
+
+// LOG_FORMAT is "human" for coloured lines or "json" for a JSON object per
+// line that journald and log tools can parse
+var LOG_FORMAT = "human"
+
+type LogFields map[string]interface{}
+
+// jsonLog writes log entries as JSON objects, lines from the log package are
+// wrapped as info entries so every line is JSON
+type jsonLog struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (j *jsonLog) Write(p []byte) (int, error) {
+	j.entry(INFO, "", strings.TrimSuffix(string(p), "\n"), nil)
+	return len(p), nil
+}
+
+func (j *jsonLog) entry(level, label, message string, fields LogFields) {
+	entry := LogFields{}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["message"] = message
+	if len(label) > 0 {
+		entry["label"] = label
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(LogFields{"time": entry["time"], "level": level, "label": label, "message": message, "error": err.Error()})
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.out.Write(append(line, '\n'))
+}
+
+var jsonLogger = &jsonLog{out: os.Stderr}
+
+func setLogFormat(format string) {
+	switch format {
+	case "json":
+		log.SetFlags(0)
+		log.SetOutput(jsonLogger)
+	case "human", "":
+		format = "human"
+		log.SetFlags(log.Ltime)
+		log.SetOutput(os.Stderr)
+	default:
+		log.Fatalf("Log format %s isn't supported, use human or json", format)
+	}
+
+	LOG_FORMAT = format
+}
+
+// LogEntry writes a log line with the fields, human lines list the fields as
+// key=value sorted by key
+func LogEntry(level, label, message string, fields LogFields) {
+	if LOG_FORMAT == "json" {
+		jsonLogger.entry(level, label, message, fields)
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	line := fmt.Sprintf("%s %s \033[0m %s", LOG_COLORS[level], label, message)
+	for _, k := range keys {
+		line += fmt.Sprintf(" %s=%v", k, fields[k])
+	}
+
+	log.Print(line)
+}
+
+// Log returns a function that logs the text with the time passed since Log was
+// called, defer it to log how long a block took
 func Log(level, label, text string, args ...interface{}) func() {
+	return LogContext(context.Background(), level, label, text, args...)
+}
+
+// LogContext is Log with the ID of the request the context belongs to
+func LogContext(ctx context.Context, level, label, text string, args ...interface{}) func() {
 	start := time.Now()
 	return func() {
+		fields := LogFields{"duration_ms": float64(time.Since(start).Microseconds()) / 1000}
 		if len(args) > 0 {
-			log.Printf("%s %s \033[0m (%s) %s %v", level, label, time.Now().Sub(start), text, args)
-		} else {
-			log.Printf("%s %s \033[0m (%s) %s", level, label, time.Now().Sub(start), text)
+			fields["args"] = fmt.Sprintf("%v", args)
+		}
+		if id, ok := ctx.Value("request_id").(string); ok {
+			fields["request_id"] = id
 		}
+
+		LogEntry(level, label, text, fields)
 	}
 }
 
+// LogError logs the error with the request it failed
+func LogError(r *http.Request, err error, fields ...LogFields) {
+	entry := LogFields{
+		"request_id": RequestID(r),
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	}
+	for _, f := range fields {
+		for k, v := range f {
+			entry[k] = v
+		}
+	}
+
+	LogEntry(ERROR, "Error", err.Error(), entry)
+}
+
 // DATABASE CONNECTION ===================================
 
 type queryLogger struct {
-	db *sqlx.DB
+	db DBTX
 }
 
 func (p queryLogger) ExecContext(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
-	defer Log(DEBUG, "DB Exec", q, args)()
+	defer LogContext(ctx, DEBUG, "DB Exec", q, args...)()
+	defer dbQueryDuration.Since(time.Now(), "exec")
+	dbQueries.Inc("exec")
 	return p.db.ExecContext(ctx, q, args...)
 }
 func (p queryLogger) PrepareContext(ctx context.Context, q string) (*sql.Stmt, error) {
 	return p.db.PrepareContext(ctx, q)
 }
 func (p queryLogger) QueryContext(ctx context.Context, q string, args ...interface{}) (*sql.Rows, error) {
-	defer Log(DEBUG, "DB Query", q, args)()
+	defer LogContext(ctx, DEBUG, "DB Query", q, args...)()
+	defer dbQueryDuration.Since(time.Now(), "query")
+	dbQueries.Inc("query")
 	return p.db.QueryContext(ctx, q, args...)
 }
 func (p queryLogger) QueryRowContext(ctx context.Context, q string, args ...interface{}) *sql.Row {
-	defer Log(DEBUG, "DB Row", q, args)()
+	defer LogContext(ctx, DEBUG, "DB Row", q, args...)()
+	defer dbQueryDuration.Since(time.Now(), "row")
+	dbQueries.Inc("row")
 	return p.db.QueryRowContext(ctx, q, args...)
 }
 
+// Transaction runs f with queries bound to a single DB transaction, it commits
+// if f succeeds and rolls back otherwise
+func Transaction(ctx context.Context, f func(*Queries) error) error {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = f(New(queryLogger{tx})); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsUniqueViolation is true when err is a unique index violation
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // ROUTES HELPERS ==========================================
 
 type HandlerFunc func(http.ResponseWriter, *http.Request) http.HandlerFunc
@@ -238,22 +739,63 @@ func handlerFuncToHttpHandler(handler HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// HTTPError is an error with the status to respond with, a message that's safe
+// to show the user and the cause that's only logged
+type HTTPError struct {
+	Status  int
+	Message string
+	Cause   error
+}
+
+func NewHTTPError(status int, message string, cause error) *HTTPError {
+	return &HTTPError{Status: status, Message: message, Cause: cause}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%d %s", e.Status, e.Message)
+	}
+
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Message, e.Cause)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// ErrorPage renders the error page of err and logs its cause with the request
+// ID, errors other than HTTPError are internal server errors
+func ErrorPage(err error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var e *HTTPError
+		if !errors.As(err, &e) {
+			e = NewHTTPError(http.StatusInternalServerError, "Something went wrong on our side, please try again later.", err)
+		}
+
+		if e.Cause != nil || e.Status >= http.StatusInternalServerError {
+			LogError(r, err)
+		}
+
+		RenderError(e.Status, e.Message)(w, r)
+	}
+}
+
 func NotFound(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, "", http.StatusNotFound)
+	RenderError(http.StatusNotFound, "The page you're looking for doesn't exist.")(w, r)
 }
 
 func BadRequest(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, "", http.StatusBadRequest)
+	RenderError(http.StatusBadRequest, "The request is invalid, please check it and try again.")(w, r)
 }
 
 func Unauthorized(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, "", http.StatusUnauthorized)
+	RenderError(http.StatusUnauthorized, "You don't have access to this page.")(w, r)
 }
 
+// InternalServerError responds with a generic message, the error is only
+// logged as it may have details the user shouldn't see
 func InternalServerError(err error) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	return ErrorPage(err)
 }
 
 func Redirect(url string) http.HandlerFunc {
@@ -262,32 +804,49 @@ func Redirect(url string) http.HandlerFunc {
 	}
 }
 
-func ROUTE(route http.HandlerFunc, checks ...RouteCheck) {
-	router.routes = append(router.routes, Route{
-		checks: checks,
+// MethodNotAllowed renders the 405 page with the methods the path allows
+func MethodNotAllowed(allow []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		RenderError(http.StatusMethodNotAllowed, fmt.Sprintf("This page can't be requested with %s.", r.Method))(w, r)
+	}
+}
+
+// ROUTE adds a route matched by its check after the routes with path patterns
+func ROUTE(method string, check RouteCheck, route http.HandlerFunc) *Route {
+	r := &Route{
+		method: method,
+		check:  check,
 		route:  route,
-	})
+		index:  router.count,
+	}
+	router.checks = append(router.checks, r)
+	router.count++
+	return r
 }
 
-func GET(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) {
-	ROUTE(
-		applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...),
-		checkMethod(http.MethodGet), checkPath(path),
-	)
+func GET(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodGet, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
 }
 
-func POST(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) {
-	ROUTE(
-		applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...),
-		checkMethod(http.MethodPost), checkPath(path),
-	)
+func POST(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodPost, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
 }
 
-func DELETE(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) {
-	ROUTE(
-		applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...),
-		checkMethod(http.MethodDelete), checkPath(path),
-	)
+func PUT(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodPut, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
+}
+
+func PATCH(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodPatch, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
+}
+
+func DELETE(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodDelete, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
+}
+
+func OPTIONS(path string, handler HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) *Route {
+	return router.add(http.MethodOptions, path, applyMiddlewares(handlerFuncToHttpHandler(handler), middlewares...))
 }
 
 // VIEWS ====================
@@ -319,28 +878,115 @@ func compileViews() {
 
 		return nil
 	})
+
+	for _, t := range templates.Templates() {
+		if t.Tree != nil {
+			checkRouteNames(t.Name(), t.Tree.Root)
+		}
+	}
+}
+
+// checkRouteNames stops the server when a template calls url_for with a route
+// name that doesn't exist
+func checkRouteNames(view string, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			checkRouteNames(view, c)
+		}
+	case *parse.ActionNode:
+		checkRouteNames(view, n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			checkRouteNames(view, c)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 1 {
+			ident, isIdent := n.Args[0].(*parse.IdentifierNode)
+			name, isString := n.Args[1].(*parse.StringNode)
+			if isIdent && ident.Ident == "url_for" && isString {
+				if _, ok := router.names[name.Text]; !ok {
+					log.Fatalf("View %s: url_for route %s doesn't exist", view, name.Text)
+				}
+			}
+		}
+		for _, c := range n.Args {
+			checkRouteNames(view, c)
+		}
+	case *parse.BranchNode:
+		checkRouteNames(view, n.Pipe)
+		checkRouteNames(view, n.List)
+		if n.ElseList != nil {
+			checkRouteNames(view, n.ElseList)
+		}
+	case *parse.IfNode:
+		checkRouteNames(view, &n.BranchNode)
+	case *parse.RangeNode:
+		checkRouteNames(view, &n.BranchNode)
+	case *parse.WithNode:
+		checkRouteNames(view, &n.BranchNode)
+	case *parse.TemplateNode:
+		checkRouteNames(view, n.Pipe)
+	}
 }
 
-func partial(path string, data interface{}) string {
+func partial(path string, data interface{}) (string, error) {
 	v := templates.Lookup(path)
 	if v == nil {
-		return fmt.Sprintf("view %s not found", path)
+		return "", fmt.Errorf("view %s not found", path)
 	}
 
 	w := bytes.NewBufferString("")
-	err := v.Execute(w, data)
-	if err != nil {
-		return "rendering error " + path + " " + err.Error()
+	if err := v.Execute(w, data); err != nil {
+		return "", err
 	}
 
-	return w.String()
+	return w.String(), nil
 }
 
+// Render executes the view before writing anything so a template error
+// responds with the error page instead of half a page
 func Render(path string, view string, data Locals) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		data["view"] = view
 		data["request"] = r
-		fmt.Fprint(w, partial(path, data))
+		out, err := partial(path, data)
+		if err != nil {
+			InternalServerError(err)(w, r)
+			return
+		}
+
+		fmt.Fprint(w, out)
+	}
+}
+
+// RenderError renders the error page in the layout with the status code, when
+// the page itself fails it falls back to the status text
+func RenderError(status int, message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := partial("layout", Locals{
+			"view":       "errors/show",
+			"request":    r,
+			"title":      http.StatusText(status),
+			"status":     status,
+			"message":    message,
+			"request_id": RequestID(r),
+		})
+		if err != nil {
+			LogError(r, err)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprint(w, out)
 	}
 }
 
@@ -372,17 +1018,25 @@ func applyMiddlewares(handler http.HandlerFunc, middlewares ...func(http.Handler
 // SERVER MIDDLEWARES ==============================
 
 func staticWithoutDirectoryListingHandler() http.HandlerFunc {
-	dir := http.Dir(STATIC_DIR_PATH)
-	server := http.FileServer(dir)
-	handler := http.StripPrefix("/", server)
+	return http.StripPrefix("/", http.FileServer(http.Dir(CONFIG.StaticDirPath))).ServeHTTP
+}
 
-	return func(w http.ResponseWriter, r *http.Request) {
+// checkStaticFile matches paths of files in the static directory, directories
+// aren't matched so they're never listed
+func checkStaticFile(dir http.Dir) RouteCheck {
+	return func(r Request) (Request, bool) {
 		if strings.HasSuffix(r.URL.Path, "/") {
-			http.NotFound(w, r)
-			return
+			return r, false
+		}
+
+		f, err := dir.Open(r.URL.Path)
+		if err != nil {
+			return r, false
 		}
+		defer f.Close()
 
-		handler.ServeHTTP(w, r)
+		stat, err := f.Stat()
+		return r, err == nil && !stat.IsDir()
 	}
 }
 
@@ -399,9 +1053,111 @@ func methodOverrideHandler(h http.Handler) http.Handler {
 	})
 }
 
+// requestIDHandler gives every request an ID to find its logs, it's sent back
+// in the X-Request-Id header
+func requestIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uuid.New().String()
+		w.Header().Set("X-Request-Id", id)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "request_id", id)))
+	})
+}
+
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value("request_id").(string)
+	return id
+}
+
+// requestLog is filled while the request is handled with the fields the
+// request logger can't get from the request itself
+type requestLog struct {
+	route string
+}
+
+// statusWriter keeps the status code and the number of bytes written
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
 func RequestLoggerHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer Log(INFO, r.Method, r.URL.Path)()
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		l := &requestLog{}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), "log", l)))
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+
+		fields := LogFields{
+			"request_id":  RequestID(r),
+			"status":      sw.status,
+			"bytes":       sw.bytes,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if len(l.route) > 0 {
+			fields["route"] = l.route
+		}
+		if id, ok := SESSION(r).Values["current_user"].(int64); ok {
+			fields["user_id"] = id
+		}
+
+		LogEntry(INFO, r.Method, r.URL.Path, fields)
+
+		status := strconv.Itoa(sw.status)
+		httpRequests.Inc(metricMethod(r.Method), l.route, status)
+		httpRequestDuration.Since(start, metricMethod(r.Method), l.route, status)
+	})
+}
+
+// metricMethod limits the methods in metrics labels to the ones routes use
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+
+	return "OTHER"
+}
+
+// recoverHandler responds with the 500 page when a handler panics and logs the
+// panic with its stack
+func recoverHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			err := fmt.Errorf("panic: %v", p)
+			LogError(r, err, LogFields{"stack": string(debug.Stack())})
+			RenderError(http.StatusInternalServerError, "Something went wrong on our side, please try again later.")(w, r)
+		}()
+
 		h.ServeHTTP(w, r)
 	})
 }
@@ -425,6 +1181,30 @@ func NullString(s string) sql.NullString {
 	}
 }
 
+func NullInt32(s string) sql.NullInt32 {
+	i, err := strconv.ParseInt(s, 10, 32)
+	return sql.NullInt32{
+		Int32: int32(i),
+		Valid: err == nil,
+	}
+}
+
+func NullInt64(s string) sql.NullInt64 {
+	i, err := strconv.ParseInt(s, 10, 64)
+	return sql.NullInt64{
+		Int64: i,
+		Valid: err == nil,
+	}
+}
+
+func NullDate(s string) sql.NullTime {
+	t, err := time.Parse("2006-01-02", s)
+	return sql.NullTime{
+		Time:  t,
+		Valid: err == nil,
+	}
+}
+
 // VALIDATION ============================
 
 type ValidationErrors map[string][]error
@@ -433,6 +1213,19 @@ func (v ValidationErrors) Add(field string, err error) {
 	v[field] = append(v[field], err)
 }
 
+// Join returns the sorted messages of all fields separated by commas
+func (v ValidationErrors) Join() string {
+	messages := []string{}
+	for _, errs := range v {
+		for _, e := range errs {
+			messages = append(messages, e.Error())
+		}
+	}
+	sort.Strings(messages)
+
+	return strings.Join(messages, ", ")
+}
+
 func ValidateStringPresent(val, key, label string, ve ValidationErrors) {
 	if len(strings.TrimSpace(val)) == 0 {
 		ve.Add(key, fmt.Errorf("%s can't be empty", label))
@@ -501,3 +1294,9 @@ func ValidateInt32Min(val int32, key, label string, ve ValidationErrors, min int
 		ve.Add(key, fmt.Errorf("%s shouldn't be less than %d", label, min))
 	}
 }
+
+func ValidateInt32Max(val int32, key, label string, ve ValidationErrors, max int32) {
+	if val > max {
+		ve.Add(key, fmt.Errorf("%s shouldn't be more than %d", label, max))
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"time"
+)
+
+const (
+	GOOGLE_COVER_URL        = "https://books.google.com/books/content?id=%s&printsec=frontcover&img=1&zoom=1"
+	GOOGLE_COVER_CACHE_PATH = "public/books/google"
+	COVERS_MIRROR_INTERVAL  = time.Hour
+	COVERS_MIRROR_DELAY     = time.Second
+	COVERS_MIRROR_BATCH     = 50
+	GOOGLE_COVER_MAX_SIZE   = 10 * MB // same as uploaded images
+)
+
+var googleBooksIDFormat = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// GoogleCover returns the path of the cached google books cover downloading it
+// first if it's not cached yet. only covers of IDs used by books, wishes or
+// metadata lookups are downloaded so it can't be used to fill the disk
+func GoogleCover(ctx context.Context, id string) (string, error) {
+	if !googleBooksIDFormat.MatchString(id) {
+		return "", fmt.Errorf("Invalid google books ID: %s", id)
+	}
+
+	p := path.Join(GOOGLE_COVER_CACHE_PATH, id)
+	if _, err := os.Stat(p); err == nil {
+		return p, nil
+	}
+
+	known, err := Q.GoogleBooksIDKnown(ctx, NullString(id))
+	if err != nil {
+		return "", err
+	}
+	if !known {
+		return "", fmt.Errorf("Unknown google books ID: %s", id)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, METADATA_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(GOOGLE_COVER_URL, id), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Google books cover %s responded with %s", id, resp.Status)
+	}
+
+	// write to a temporary file first so a failed download is never served
+	tmp, err := os.CreateTemp(GOOGLE_COVER_CACHE_PATH, id+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, GOOGLE_COVER_MAX_SIZE+1))
+	if err == nil && n > GOOGLE_COVER_MAX_SIZE {
+		err = fmt.Errorf("Google books cover %s is larger than %d bytes", id, GOOGLE_COVER_MAX_SIZE)
+	}
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	return p, os.Rename(tmp.Name(), p)
+}
+
+// MirrorCovers downloads the covers of books that only have a google books ID
+// and sets them as the book image then repeats every COVERS_MIRROR_INTERVAL
+func MirrorCovers() {
+	for {
+		mirrorCovers(context.Background())
+		time.Sleep(COVERS_MIRROR_INTERVAL)
+	}
+}
+
+func mirrorCovers(ctx context.Context) {
+	var lastID int64
+	for {
+		books, err := Q.BooksWithoutCover(ctx, BooksWithoutCoverParams{
+			ID:    lastID,
+			Limit: COVERS_MIRROR_BATCH,
+		})
+		if err != nil {
+			log.Printf("Mirroring covers stopped: %s", err)
+			return
+		}
+
+		if len(books) == 0 {
+			return
+		}
+
+		for _, book := range books {
+			lastID = book.ID
+			if err := mirrorCover(ctx, book); err != nil {
+				log.Printf("Mirroring cover failed for book %d: %s", book.ID, err)
+			}
+
+			time.Sleep(COVERS_MIRROR_DELAY)
+		}
+	}
+}
+
+func mirrorCover(ctx context.Context, book BooksWithoutCoverRow) error {
+	p, err := GoogleCover(ctx, book.GoogleBooksID.String)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name, err := UploadImage(f, BOOK_COVER_PATH, 432, 576)
+	if err != nil {
+		return err
+	}
+
+	err = Q.UpdateBookImage(ctx, UpdateBookImageParams{
+		Image: NullString(name),
+		ID:    book.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the book serves the mirrored image now, the proxy downloads the cover
+	// again if anything else asks for it
+	if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -4,4 +4,4 @@ ALTER TABLE books
 
 -- down
 ALTER TABLE books
-  DROP COLUMN page_read integer NOT NULL DEFAULT 0;
+  DROP COLUMN page_read;
@@ -0,0 +1,50 @@
+-- up
+CREATE TABLE works (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title character varying NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  book_id bigint
+);
+CREATE INDEX index_works_on_user_id ON works USING btree (user_id);
+
+-- every existing book becomes the only edition of its own work
+INSERT INTO works (user_id, title, created_at, updated_at, book_id)
+SELECT user_id, title, created_at, updated_at, id FROM books;
+
+ALTER TABLE books ADD COLUMN work_id bigint REFERENCES works(id) ON DELETE CASCADE;
+UPDATE books SET work_id = works.id FROM works WHERE works.book_id = books.id;
+ALTER TABLE books ALTER COLUMN work_id SET NOT NULL;
+ALTER TABLE works DROP COLUMN book_id;
+CREATE INDEX index_books_on_work_id ON books USING btree (work_id);
+
+CREATE TABLE copies (
+  id bigserial PRIMARY KEY,
+  book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  shelf_id bigint REFERENCES shelves(id) ON DELETE SET NULL,
+  condition character varying DEFAULT '' NOT NULL,
+  acquired_at date,
+  lent_to character varying,
+  lent_at timestamp(6) without time zone,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX index_copies_on_book_id ON copies USING btree (book_id);
+CREATE INDEX index_copies_on_shelf_id ON copies USING btree (shelf_id);
+
+-- every existing book is one physical copy on its current shelf
+INSERT INTO copies (book_id, shelf_id, created_at, updated_at)
+SELECT id, shelf_id, created_at, created_at FROM books;
+
+ALTER TABLE books DROP COLUMN shelf_id;
+
+-- down
+ALTER TABLE books ADD COLUMN shelf_id bigint REFERENCES shelves(id) ON DELETE SET NULL;
+UPDATE books
+   SET shelf_id = (SELECT shelf_id FROM copies WHERE copies.book_id = books.id ORDER BY copies.id LIMIT 1);
+CREATE INDEX index_books_on_shelf_id ON books USING btree (shelf_id);
+
+DROP TABLE copies;
+ALTER TABLE books DROP COLUMN work_id;
+DROP TABLE works;
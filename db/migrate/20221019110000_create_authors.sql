@@ -0,0 +1,39 @@
+-- up
+CREATE TABLE authors (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX index_authors_on_user_id_and_name ON authors USING btree (user_id, name);
+
+CREATE TABLE book_authors (
+  book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  author_id bigint NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+  role character varying DEFAULT 'author' NOT NULL,
+  position integer DEFAULT 0 NOT NULL,
+  PRIMARY KEY (book_id, author_id, role)
+);
+CREATE INDEX index_book_authors_on_author_id ON book_authors USING btree (author_id);
+
+-- best effort: "A, B & C and D" becomes four authors
+INSERT INTO authors (user_id, name)
+SELECT DISTINCT user_id, trim(parts.name)
+  FROM books,
+       LATERAL regexp_split_to_table(books.author, '\s*(,|;|&|\s+and\s+)\s*') AS parts(name)
+ WHERE trim(parts.name) <> ''
+    ON CONFLICT DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT books.id, authors.id, 'author', parts.position
+  FROM books,
+       LATERAL regexp_split_to_table(books.author, '\s*(,|;|&|\s+and\s+)\s*') WITH ORDINALITY AS parts(name, position),
+       authors
+ WHERE authors.user_id = books.user_id
+   AND authors.name = trim(parts.name)
+    ON CONFLICT DO NOTHING;
+
+-- down
+DROP TABLE book_authors;
+DROP TABLE authors;
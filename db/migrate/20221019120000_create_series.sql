@@ -0,0 +1,22 @@
+-- up
+CREATE TABLE series (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  volumes integer,
+  google_series_id character varying,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX index_series_on_user_id_and_name ON series USING btree (user_id, name);
+
+ALTER TABLE works
+  ADD COLUMN series_id bigint REFERENCES series(id) ON DELETE SET NULL,
+  ADD COLUMN series_position integer;
+CREATE INDEX index_works_on_series_id ON works USING btree (series_id);
+
+-- down
+ALTER TABLE works
+  DROP COLUMN series_id,
+  DROP COLUMN series_position;
+DROP TABLE series;
@@ -0,0 +1,20 @@
+-- up
+CREATE TABLE tags (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name character varying NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX index_tags_on_user_id_and_name ON tags USING btree (user_id, name);
+
+CREATE TABLE book_tags (
+  book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  tag_id bigint NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (book_id, tag_id)
+);
+CREATE INDEX index_book_tags_on_tag_id ON book_tags USING btree (tag_id);
+
+-- down
+DROP TABLE book_tags;
+DROP TABLE tags;
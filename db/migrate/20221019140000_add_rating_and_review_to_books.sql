@@ -0,0 +1,8 @@
+-- up
+-- rating is in half stars, 1 is half a star and 10 is five stars
+ALTER TABLE books ADD COLUMN rating smallint CHECK (rating BETWEEN 1 AND 10);
+ALTER TABLE books ADD COLUMN review text DEFAULT '' NOT NULL;
+
+-- down
+ALTER TABLE books DROP COLUMN rating;
+ALTER TABLE books DROP COLUMN review;
@@ -0,0 +1,26 @@
+-- up
+CREATE TABLE wishes (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  isbn character varying DEFAULT '' NOT NULL,
+  title character varying NOT NULL,
+  subtitle character varying DEFAULT '' NOT NULL,
+  author character varying DEFAULT '' NOT NULL,
+  description text DEFAULT '' NOT NULL,
+  publisher character varying DEFAULT '' NOT NULL,
+  page_count integer DEFAULT 0 NOT NULL,
+  google_books_id character varying,
+  series_name character varying DEFAULT '' NOT NULL,
+  series_position integer,
+  priority smallint DEFAULT 1 NOT NULL,
+  note text DEFAULT '' NOT NULL,
+  source character varying DEFAULT '' NOT NULL,
+  reserved_by character varying,
+  reserved_at timestamp(6) without time zone,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX index_wishes_on_user_id ON wishes USING btree (user_id);
+
+-- down
+DROP TABLE wishes;
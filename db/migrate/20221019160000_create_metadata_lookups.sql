@@ -0,0 +1,12 @@
+-- up
+CREATE TABLE metadata_lookups (
+  id bigserial PRIMARY KEY,
+  provider character varying NOT NULL,
+  query character varying NOT NULL,
+  results jsonb NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX index_metadata_lookups_on_provider_and_query ON metadata_lookups USING btree (provider, query);
+
+-- down
+DROP TABLE metadata_lookups;
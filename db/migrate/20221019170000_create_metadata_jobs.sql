@@ -0,0 +1,21 @@
+-- up
+CREATE TABLE metadata_jobs (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status character varying DEFAULT 'running' NOT NULL,
+  last_book_id bigint DEFAULT 0 NOT NULL,
+  processed integer DEFAULT 0 NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX index_metadata_jobs_on_user_id ON metadata_jobs USING btree (user_id);
+
+CREATE TABLE metadata_suggestions (
+  book_id bigint PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+  metadata jsonb NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- down
+DROP TABLE metadata_suggestions;
+DROP TABLE metadata_jobs;
@@ -0,0 +1,20 @@
+-- up
+ALTER TABLE books ADD COLUMN deleted_at timestamp(6) without time zone;
+ALTER TABLE highlights ADD COLUMN deleted_at timestamp(6) without time zone;
+ALTER TABLE shelves ADD COLUMN deleted_at timestamp(6) without time zone;
+
+-- a trashed book shouldn't stop adding the same ISBN again
+DROP INDEX index_books_on_user_id_and_isbn;
+CREATE UNIQUE INDEX index_books_on_user_id_and_isbn ON books USING btree (user_id, isbn) WHERE deleted_at IS NULL;
+
+-- down
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+DELETE FROM highlights WHERE deleted_at IS NOT NULL;
+DELETE FROM shelves WHERE deleted_at IS NOT NULL;
+
+DROP INDEX index_books_on_user_id_and_isbn;
+CREATE UNIQUE INDEX index_books_on_user_id_and_isbn ON books USING btree (user_id, isbn);
+
+ALTER TABLE shelves DROP COLUMN deleted_at;
+ALTER TABLE highlights DROP COLUMN deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
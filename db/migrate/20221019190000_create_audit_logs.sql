@@ -0,0 +1,15 @@
+-- up
+CREATE TABLE audit_logs (
+  id bigserial PRIMARY KEY,
+  actor_id bigint REFERENCES users(id) ON DELETE SET NULL,
+  book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  entity character varying NOT NULL,
+  entity_id bigint NOT NULL,
+  action character varying NOT NULL,
+  changes jsonb DEFAULT '{}' NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX index_audit_logs_on_book_id ON audit_logs USING btree (book_id);
+
+-- down
+DROP TABLE audit_logs;
@@ -0,0 +1,17 @@
+-- up
+CREATE TABLE notes (
+  id bigserial PRIMARY KEY,
+  book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  highlight_id bigint REFERENCES highlights(id) ON DELETE SET NULL,
+  page_from integer,
+  page_to integer,
+  content text NOT NULL,
+  private boolean DEFAULT true NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX index_notes_on_book_id ON notes USING btree (book_id);
+CREATE INDEX index_notes_on_highlight_id ON notes USING btree (highlight_id);
+
+-- down
+DROP TABLE notes;
@@ -0,0 +1,5 @@
+-- up
+ALTER TABLE users ADD COLUMN admin boolean DEFAULT false NOT NULL;
+
+-- down
+ALTER TABLE users DROP COLUMN admin;
@@ -0,0 +1,7 @@
+-- up
+ALTER TABLE wishes ADD COLUMN reserver_id bigint REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE wishes ADD COLUMN reserver_token character varying;
+
+-- down
+ALTER TABLE wishes DROP COLUMN reserver_token;
+ALTER TABLE wishes DROP COLUMN reserver_id;
@@ -0,0 +1,24 @@
+-- up
+CREATE TABLE bulk_adds (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  shelf_id bigint REFERENCES shelves(id) ON DELETE SET NULL,
+  status character varying DEFAULT 'running' NOT NULL,
+  created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+CREATE INDEX index_bulk_adds_on_user_id ON bulk_adds USING btree (user_id);
+
+CREATE TABLE bulk_add_books (
+  id bigserial PRIMARY KEY,
+  bulk_add_id bigint NOT NULL REFERENCES bulk_adds(id) ON DELETE CASCADE,
+  isbn character varying(13) NOT NULL,
+  status character varying DEFAULT 'waiting' NOT NULL,
+  title character varying DEFAULT '' NOT NULL,
+  error character varying DEFAULT '' NOT NULL
+);
+CREATE INDEX index_bulk_add_books_on_bulk_add_id ON bulk_add_books USING btree (bulk_add_id);
+
+-- down
+DROP TABLE bulk_add_books;
+DROP TABLE bulk_adds;
@@ -0,0 +1,18 @@
+-- up
+ALTER TABLE audit_logs DROP CONSTRAINT audit_logs_book_id_fkey;
+ALTER TABLE audit_logs ADD COLUMN user_id bigint REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE audit_logs ADD COLUMN book_title character varying DEFAULT '' NOT NULL;
+UPDATE audit_logs
+   SET user_id = books.user_id,
+       book_title = books.title
+  FROM books
+ WHERE books.id = audit_logs.book_id;
+ALTER TABLE audit_logs ALTER COLUMN user_id SET NOT NULL;
+CREATE INDEX index_audit_logs_on_user_id ON audit_logs USING btree (user_id);
+
+-- down
+DELETE FROM audit_logs WHERE NOT EXISTS (SELECT 1 FROM books WHERE books.id = audit_logs.book_id);
+DROP INDEX index_audit_logs_on_user_id;
+ALTER TABLE audit_logs DROP COLUMN book_title;
+ALTER TABLE audit_logs DROP COLUMN user_id;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_book_id_fkey FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE;
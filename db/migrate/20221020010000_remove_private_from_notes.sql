@@ -0,0 +1,5 @@
+-- up
+ALTER TABLE notes DROP COLUMN private;
+
+-- down
+ALTER TABLE notes ADD COLUMN private boolean DEFAULT true NOT NULL;
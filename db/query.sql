@@ -13,38 +13,46 @@ VALUES($1,$2,$3,$4)
        RETURNING id;
 
 -- name: UserUnshelvedBooks :many
-SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_count, page_read
-  FROM books, users
- WHERE users.id = books.user_id
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_count, page_read, copies.id copy_id, rating
+  FROM copies, books, users
+ WHERE books.id = copies.book_id
+   AND users.id = books.user_id
    AND user_id = $1
-   AND shelf_id IS NULL;
+   AND books.deleted_at IS NULL
+   AND NOT EXISTS (SELECT 1 FROM shelves WHERE shelves.id = copies.shelf_id AND shelves.deleted_at IS NULL)
+ ORDER BY CASE WHEN sqlc.arg(top_rated)::boolean THEN rating END DESC NULLS LAST, copies.created_at DESC;
 
 -- name: Shelves :many
-SELECT * FROM shelves WHERE user_id = $1 ORDER BY position;
+SELECT * FROM shelves WHERE user_id = $1 AND deleted_at IS NULL ORDER BY position;
 
 -- name: ShelfBooks :many
-SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count
-  FROM books, users
- WHERE users.id = books.user_id
-   AND shelf_id = $1
- ORDER BY books.created_at DESC;
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, copies.id copy_id, rating
+  FROM copies, books, users
+ WHERE books.id = copies.book_id
+   AND users.id = books.user_id
+   AND copies.shelf_id = $1
+   AND books.deleted_at IS NULL
+   AND EXISTS (SELECT 1 FROM shelves WHERE shelves.id = copies.shelf_id AND shelves.deleted_at IS NULL)
+ ORDER BY CASE WHEN sqlc.arg(top_rated)::boolean THEN rating END DESC NULLS LAST, copies.created_at DESC;
 
 -- name: BookByIsbnAndUser :one
-SELECT books.*, slug, shelves.name shelf_name
-  FROM users, books
-       LEFT JOIN shelves
-           ON shelves.id = books.shelf_id
+SELECT books.*, slug, works.title work_title, works.series_id, works.series_position, series.name series_name
+  FROM users, books, works
+       LEFT JOIN series
+           ON series.id = works.series_id
  WHERE users.id = books.user_id
+   AND works.id = books.work_id
    AND books.user_id = $1
    AND isbn = $2
+   AND books.deleted_at IS NULL
  LIMIT 1;
 
 -- name: Highlights :many
-SELECT * FROM highlights WHERE book_id = $1 ORDER BY page;
+SELECT * FROM highlights WHERE book_id = $1 AND deleted_at IS NULL ORDER BY page;
 
 -- name: NewBook :one
-INSERT INTO books (title, isbn, author, subtitle, description, publisher, page_count, google_books_id, user_id, page_read)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+INSERT INTO books (title, isbn, author, subtitle, description, publisher, page_count, google_books_id, user_id, page_read, work_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
        RETURNING *;
 
 -- name: UpdateBook :exec
@@ -59,6 +67,13 @@ UPDATE books
        updated_at = CURRENT_TIMESTAMP
  WHERE id = $8;
 
+-- name: UpdateBookReview :exec
+UPDATE books
+   SET rating = $1,
+       review = $2,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $3;
+
 -- name: UpdateBookImage :exec
 UPDATE books SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
 
@@ -66,10 +81,10 @@ UPDATE books SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
 UPDATE books SET page_read = page_count WHERE id = $1;
 
 -- name: ShelfByIdAndUser :one
-SELECT * FROM shelves WHERE user_id = $1 AND id = $2 LIMIT 1;
+SELECT * FROM shelves WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL LIMIT 1;
 
 -- name: HighlightByIDAndBook :one
-SELECT * FROM highlights WHERE id = $1 AND book_id = $2 LIMIT 1;
+SELECT * FROM highlights WHERE id = $1 AND book_id = $2 AND deleted_at IS NULL LIMIT 1;
 
 -- name: UpdateUser :exec
 UPDATE users
@@ -99,28 +114,27 @@ INSERT INTO shelves (name, user_id, position)
 VALUES ($1, $2, (
   SELECT coalesce(MAX(position), 0) + 1
     FROM shelves
-   WHERE user_id = $2)
+   WHERE user_id = $2
+     AND deleted_at IS NULL)
 );
 
 -- name: UpdateShelf :exec
 UPDATE shelves SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
 
--- name: DeleteBook :exec
-DELETE FROM books WHERE id = $1;
-
--- name: DeleteHighlight :exec
-DELETE FROM highlights WHERE id = $1;
+-- name: TrashBook :exec
+UPDATE books SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1;
 
--- name: HighlightsWithImages :many
-SELECT image FROM highlights WHERE image IS NOT NULL AND length(image) > 0 AND book_id = $1;
+-- name: TrashHighlight :exec
+UPDATE highlights SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1;
 
 -- name: RemoveShelf :exec
 UPDATE shelves SET position = position - 1
  WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
-   AND position > (SELECT position FROM shelves WHERE shelves.id = $1);
+   AND position > (SELECT position FROM shelves WHERE shelves.id = $1)
+   AND deleted_at IS NULL;
 
--- name: DeleteShelf :exec
-DELETE FROM shelves WHERE id = $1;
+-- name: TrashShelf :exec
+UPDATE shelves SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1;
 
 -- name: MoveShelfUp :exec
 UPDATE shelves
@@ -134,7 +148,8 @@ UPDATE shelves
    AND position IN (
      (SELECT position -1 FROM shelves WHERE shelves.id = $1),
      (SELECT position FROM shelves WHERE shelves.id = $1)
-   );
+   )
+   AND deleted_at IS NULL;
 
 -- name: MoveShelfDown :exec
 UPDATE shelves
@@ -148,10 +163,596 @@ UPDATE shelves
    AND position IN (
      (SELECT position FROM shelves WHERE shelves.id = $1),
      (SELECT position + 1 FROM shelves WHERE shelves.id = $1)
-   );
+   )
+   AND deleted_at IS NULL;
+
+-- name: MoveCopyToShelf :exec
+UPDATE copies SET shelf_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
 
--- name: MoveBookToShelf :exec
-UPDATE books SET shelf_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
+-- name: CopyByIDAndUser :one
+SELECT copies.id, copies.book_id, isbn
+  FROM copies, books
+ WHERE books.id = copies.book_id
+   AND books.user_id = $1
+   AND copies.id = $2
+   AND books.deleted_at IS NULL
+ LIMIT 1;
 
 -- name: BooksCount :one
-SELECT count(*) FROM books WHERE user_id = $1;
+SELECT count(*) FROM books WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: NewWork :one
+INSERT INTO works (user_id, title) VALUES ($1, $2) RETURNING *;
+
+-- name: MoveBookToWork :exec
+UPDATE books SET work_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
+
+-- name: DeleteOrphanWorks :exec
+DELETE FROM works
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM books WHERE books.work_id = works.id);
+
+-- name: WorkEditions :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, publisher
+  FROM books, users
+ WHERE users.id = books.user_id
+   AND work_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY books.created_at;
+
+-- name: WorkHighlights :many
+SELECT highlights.*, isbn
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND work_id = $1
+   AND books.deleted_at IS NULL
+   AND highlights.deleted_at IS NULL
+ ORDER BY page;
+
+-- name: BookCopies :many
+SELECT copies.*, shelves.name shelf_name
+  FROM copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE book_id = $1
+ ORDER BY copies.id;
+
+-- name: CopyByIDAndBook :one
+SELECT * FROM copies WHERE id = $1 AND book_id = $2 LIMIT 1;
+
+-- name: NewCopy :one
+INSERT INTO copies (book_id, shelf_id) VALUES ($1, $2) RETURNING *;
+
+-- name: UpdateCopy :exec
+UPDATE copies
+   SET condition = $1,
+       acquired_at = $2,
+       lent_to = $3,
+       lent_at = $4,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $5;
+
+-- name: DeleteCopy :exec
+DELETE FROM copies WHERE id = $1;
+
+-- name: UpsertAuthor :one
+INSERT INTO authors (user_id, name)
+VALUES ($1, $2)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET updated_at = authors.updated_at
+       RETURNING *;
+
+-- name: DeleteBookAuthors :exec
+DELETE FROM book_authors WHERE book_id = $1;
+
+-- name: NewBookAuthor :exec
+INSERT INTO book_authors (book_id, author_id, role, position)
+VALUES ($1, $2, $3, $4)
+       ON CONFLICT DO NOTHING;
+
+-- name: RefreshBookAuthor :exec
+UPDATE books
+   SET author = coalesce((
+     SELECT string_agg(
+              CASE role WHEN 'author' THEN name ELSE name || ' (' || role || ')' END,
+              ', ' ORDER BY position)
+       FROM book_authors, authors
+      WHERE authors.id = book_authors.author_id
+        AND book_authors.book_id = books.id
+   ), '')
+ WHERE id = $1;
+
+-- name: RefreshAuthorBooks :exec
+UPDATE books
+   SET author = coalesce((
+     SELECT string_agg(
+              CASE role WHEN 'author' THEN name ELSE name || ' (' || role || ')' END,
+              ', ' ORDER BY position)
+       FROM book_authors, authors
+      WHERE authors.id = book_authors.author_id
+        AND book_authors.book_id = books.id
+   ), ''),
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = $1);
+
+-- name: BookAuthors :many
+SELECT authors.id id, name, role
+  FROM book_authors, authors
+ WHERE authors.id = book_authors.author_id
+   AND book_id = $1
+ ORDER BY position;
+
+-- name: Authors :many
+SELECT authors.*, count(books.id) books_count
+  FROM authors
+       LEFT JOIN book_authors
+           ON book_authors.author_id = authors.id
+       LEFT JOIN books
+           ON books.id = book_authors.book_id
+          AND books.deleted_at IS NULL
+ WHERE authors.user_id = $1
+ GROUP BY authors.id
+HAVING count(books.id) > 0
+ ORDER BY name;
+
+-- name: AuthorByIDAndUser :one
+SELECT * FROM authors WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: AuthorBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, role
+  FROM book_authors, books, users
+ WHERE books.id = book_authors.book_id
+   AND users.id = books.user_id
+   AND author_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY title;
+
+-- name: UpdateAuthor :exec
+UPDATE authors SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
+
+-- name: MergeAuthor :exec
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT book_id, sqlc.arg(target_id)::bigint, role, position
+  FROM book_authors
+ WHERE author_id = sqlc.arg(duplicate_id)
+    ON CONFLICT DO NOTHING;
+
+-- name: DeleteAuthor :exec
+DELETE FROM authors WHERE id = $1;
+
+-- name: DeleteOrphanAuthors :exec
+DELETE FROM authors
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM book_authors WHERE book_authors.author_id = authors.id);
+
+-- name: AuthorByNameAndUser :one
+SELECT * FROM authors WHERE name = $1 AND user_id = $2 LIMIT 1;
+
+-- name: UpsertSeries :one
+INSERT INTO series (user_id, name, google_series_id)
+VALUES ($1, $2, $3)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET google_series_id = coalesce(EXCLUDED.google_series_id, series.google_series_id)
+       RETURNING *;
+
+-- name: SetWorkSeries :exec
+UPDATE works
+   SET series_id = $1,
+       series_position = $2,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $3;
+
+-- name: SeriesByIDAndUser :one
+SELECT * FROM series WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: SeriesByNameAndUser :one
+SELECT * FROM series WHERE name = $1 AND user_id = $2 LIMIT 1;
+
+-- name: UserSeries :many
+SELECT series.*, count(works.id) works_count
+  FROM series
+       LEFT JOIN works
+           ON works.series_id = series.id
+          AND EXISTS (SELECT 1 FROM books WHERE books.work_id = works.id AND books.deleted_at IS NULL)
+ WHERE series.user_id = $1
+ GROUP BY series.id
+HAVING count(works.id) > 0
+ ORDER BY name;
+
+-- name: SeriesBooks :many
+SELECT DISTINCT ON (works.series_position, works.id)
+       books.id id, books.title title, books.image image, google_books_id, slug, isbn, page_read, page_count, works.id work_id, works.series_position
+  FROM works, books, users
+ WHERE books.work_id = works.id
+   AND users.id = books.user_id
+   AND works.series_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY works.series_position, works.id, books.created_at;
+
+-- name: UpdateSeries :exec
+UPDATE series SET name = $1, volumes = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3;
+
+-- name: DeleteOrphanSeries :exec
+DELETE FROM series
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM works WHERE works.series_id = series.id);
+
+-- name: UpsertTag :one
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET updated_at = tags.updated_at
+       RETURNING *;
+
+-- name: DeleteBookTags :exec
+DELETE FROM book_tags WHERE book_id = $1;
+
+-- name: NewBookTag :exec
+INSERT INTO book_tags (book_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: BookTags :many
+SELECT tags.*
+  FROM book_tags, tags
+ WHERE tags.id = book_tags.tag_id
+   AND book_id = $1
+ ORDER BY name;
+
+-- name: UserTags :many
+SELECT tags.*, count(book_tags.book_id) books_count
+  FROM tags, book_tags, books
+ WHERE book_tags.tag_id = tags.id
+   AND books.id = book_tags.book_id
+   AND tags.user_id = $1
+   AND books.deleted_at IS NULL
+ GROUP BY tags.id
+ ORDER BY name;
+
+-- name: TagByNameAndUser :one
+SELECT * FROM tags WHERE name = $1 AND user_id = $2 LIMIT 1;
+
+-- name: TagBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, copies.id copy_id, shelves.id shelf_id
+  FROM book_tags, books, users, copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE books.id = book_tags.book_id
+   AND users.id = books.user_id
+   AND copies.book_id = books.id
+   AND tag_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY copies.created_at DESC;
+
+-- name: DeleteOrphanTags :exec
+DELETE FROM tags
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM book_tags WHERE book_tags.tag_id = tags.id);
+
+-- name: UserWishes :many
+SELECT * FROM wishes WHERE user_id = $1 ORDER BY priority DESC, created_at DESC;
+
+-- name: WishByIDAndUser :one
+SELECT * FROM wishes WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: NewWish :one
+INSERT INTO wishes (user_id, isbn, title, subtitle, author, description, publisher, page_count, google_books_id, series_name, series_position, priority, note, source)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+       RETURNING *;
+
+-- name: UpdateWish :exec
+UPDATE wishes
+   SET isbn = $1,
+       title = $2,
+       subtitle = $3,
+       author = $4,
+       description = $5,
+       publisher = $6,
+       page_count = $7,
+       google_books_id = $8,
+       series_name = $9,
+       series_position = $10,
+       priority = $11,
+       note = $12,
+       source = $13,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $14;
+
+-- name: ReserveWish :exec
+UPDATE wishes
+   SET reserved_by = $1,
+       reserver_id = $2,
+       reserver_token = $3,
+       reserved_at = CURRENT_TIMESTAMP
+ WHERE id = $4
+   AND reserved_by IS NULL;
+
+-- name: UnreserveWish :exec
+UPDATE wishes SET reserved_by = NULL, reserver_id = NULL, reserver_token = NULL, reserved_at = NULL WHERE id = $1;
+
+-- name: DeleteWish :exec
+DELETE FROM wishes WHERE id = $1;
+
+-- name: MetadataLookup :one
+SELECT * FROM metadata_lookups
+ WHERE provider = $1
+   AND query = $2
+   AND created_at > $3
+ LIMIT 1;
+
+-- name: SaveMetadataLookup :exec
+INSERT INTO metadata_lookups (provider, query, results)
+VALUES ($1, $2, $3)
+    ON CONFLICT (provider, query)
+    DO UPDATE SET results = EXCLUDED.results, created_at = CURRENT_TIMESTAMP;
+
+-- name: MetadataJobByUser :one
+SELECT * FROM metadata_jobs WHERE user_id = $1 LIMIT 1;
+
+-- name: StartMetadataJob :one
+INSERT INTO metadata_jobs (user_id) VALUES ($1)
+    ON CONFLICT (user_id)
+    DO UPDATE SET status = 'running', last_book_id = 0, processed = 0, updated_at = CURRENT_TIMESTAMP
+       RETURNING *;
+
+-- name: RunningMetadataJobs :many
+SELECT * FROM metadata_jobs WHERE status = 'running';
+
+-- name: MetadataJobProgress :exec
+UPDATE metadata_jobs
+   SET last_book_id = $1,
+       processed = processed + 1,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $2;
+
+-- name: FinishMetadataJob :exec
+UPDATE metadata_jobs SET status = 'done', updated_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: NextBookToEnrich :one
+SELECT id, isbn
+  FROM books
+ WHERE user_id = $1
+   AND id > $2
+   AND deleted_at IS NULL
+   AND (description = '' OR publisher = '' OR page_count = 0 OR google_books_id IS NULL)
+ ORDER BY id
+ LIMIT 1;
+
+-- name: SaveMetadataSuggestion :exec
+INSERT INTO metadata_suggestions (book_id, metadata)
+VALUES ($1, $2)
+    ON CONFLICT (book_id)
+    DO UPDATE SET metadata = EXCLUDED.metadata, created_at = CURRENT_TIMESTAMP;
+
+-- name: MetadataSuggestion :one
+SELECT * FROM metadata_suggestions WHERE book_id = $1 LIMIT 1;
+
+-- name: DeleteMetadataSuggestion :exec
+DELETE FROM metadata_suggestions WHERE book_id = $1;
+
+-- name: UserMetadataSuggestions :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count
+  FROM metadata_suggestions, books, users
+ WHERE books.id = metadata_suggestions.book_id
+   AND users.id = books.user_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY books.id;
+
+-- name: UpdateBookGoogleBooksID :exec
+UPDATE books SET google_books_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
+
+-- name: BooksWithoutCover :many
+SELECT id, google_books_id
+  FROM books
+ WHERE image IS NULL
+   AND google_books_id IS NOT NULL
+   AND deleted_at IS NULL
+   AND id > $1
+ ORDER BY id
+ LIMIT $2;
+
+-- name: GoogleBooksIDKnown :one
+SELECT EXISTS (SELECT 1 FROM books WHERE google_books_id = $1)
+    OR EXISTS (SELECT 1 FROM wishes WHERE google_books_id = $1)
+    OR EXISTS (
+         SELECT 1 FROM metadata_lookups
+          WHERE provider = 'google_books'
+            AND results @> jsonb_build_array(jsonb_build_object('GoogleBooksID', $1::varchar))
+       );
+
+-- name: NewBulkAdd :one
+INSERT INTO bulk_adds (user_id, shelf_id) VALUES ($1, $2) RETURNING *;
+
+-- name: NewBulkAddBook :exec
+INSERT INTO bulk_add_books (bulk_add_id, isbn, status, error) VALUES ($1, $2, $3, $4);
+
+-- name: BulkAddByIDAndUser :one
+SELECT * FROM bulk_adds WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: UserBulkAdds :many
+SELECT * FROM bulk_adds WHERE user_id = $1 ORDER BY created_at DESC LIMIT 5;
+
+-- name: RunningBulkAdds :many
+SELECT * FROM bulk_adds WHERE status = 'running';
+
+-- name: BulkAddBooks :many
+SELECT * FROM bulk_add_books WHERE bulk_add_id = $1 ORDER BY id;
+
+-- name: NextBulkAddBook :one
+SELECT * FROM bulk_add_books
+ WHERE bulk_add_id = $1
+   AND status = 'waiting'
+ ORDER BY id
+ LIMIT 1;
+
+-- name: UpdateBulkAddBook :exec
+UPDATE bulk_add_books SET status = $1, title = $2, error = $3 WHERE id = $4;
+
+-- name: FinishBulkAdd :exec
+UPDATE bulk_adds SET status = 'done', updated_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: TrashedBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, deleted_at
+  FROM books, users
+ WHERE users.id = books.user_id
+   AND books.user_id = $1
+   AND deleted_at IS NOT NULL
+ ORDER BY deleted_at DESC;
+
+-- name: TrashedHighlights :many
+SELECT highlights.*, books.title book_title, isbn
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+   AND highlights.deleted_at IS NOT NULL
+ ORDER BY highlights.deleted_at DESC;
+
+-- name: TrashedShelves :many
+SELECT * FROM shelves WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC;
+
+-- name: TrashedBookByIDAndUser :one
+SELECT * FROM books WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL LIMIT 1;
+
+-- name: RestoreBook :exec
+UPDATE books SET deleted_at = NULL WHERE id = $1;
+
+-- name: RestoreHighlight :one
+UPDATE highlights
+   SET deleted_at = NULL
+ WHERE id = $1
+   AND deleted_at IS NOT NULL
+   AND book_id IN (SELECT id FROM books WHERE user_id = $2 AND deleted_at IS NULL)
+       RETURNING *;
+
+-- name: RestoreShelf :one
+UPDATE shelves
+   SET deleted_at = NULL,
+       position = (
+         SELECT coalesce(MAX(position), 0) + 1
+           FROM shelves
+          WHERE user_id = $2
+            AND deleted_at IS NULL
+       )
+ WHERE id = $1
+   AND user_id = $2
+   AND deleted_at IS NOT NULL
+       RETURNING id;
+
+-- name: ExpiredBookImages :many
+SELECT image
+  FROM books
+ WHERE deleted_at < $1
+   AND image IS NOT NULL
+   AND length(image) > 0;
+
+-- name: ExpiredHighlightImages :many
+SELECT highlights.image
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND (highlights.deleted_at < $1 OR books.deleted_at < $1)
+   AND highlights.image IS NOT NULL
+   AND length(highlights.image) > 0;
+
+-- name: PurgeHighlights :exec
+DELETE FROM highlights WHERE deleted_at < $1;
+
+-- name: PurgeShelves :exec
+DELETE FROM shelves WHERE deleted_at < $1;
+
+-- name: PurgeBooks :many
+DELETE FROM books WHERE deleted_at < $1 RETURNING user_id;
+
+-- name: BookByID :one
+SELECT * FROM books WHERE id = $1 LIMIT 1;
+
+-- name: CopyShelfName :one
+SELECT shelves.name
+  FROM copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE copies.id = $1
+ LIMIT 1;
+
+-- name: NewAuditLog :exec
+INSERT INTO audit_logs (actor_id, book_id, user_id, book_title, entity, entity_id, action, changes)
+SELECT $1, id, user_id, title, $3, $4, $5, $6 FROM books WHERE id = $2;
+
+-- name: BookAuditLogs :many
+SELECT audit_logs.*, users.name actor_name
+  FROM audit_logs
+       LEFT JOIN users
+           ON users.id = audit_logs.actor_id
+ WHERE book_id = $1
+ ORDER BY audit_logs.created_at DESC, audit_logs.id DESC;
+
+-- name: AuditLogByIDAndBook :one
+SELECT * FROM audit_logs WHERE id = $1 AND book_id = $2 LIMIT 1;
+
+-- name: BookNotes :many
+SELECT notes.*, highlights.page highlight_page, highlights.content highlight_content
+  FROM notes
+       LEFT JOIN highlights
+           ON highlights.id = notes.highlight_id
+          AND highlights.deleted_at IS NULL
+ WHERE notes.book_id = $1
+ ORDER BY coalesce(notes.page_from, highlights.page) NULLS LAST, notes.created_at;
+
+-- name: NoteByIDAndBook :one
+SELECT * FROM notes WHERE id = $1 AND book_id = $2 LIMIT 1;
+
+-- name: NewNote :one
+INSERT INTO notes (book_id, highlight_id, page_from, page_to, content)
+VALUES ($1, $2, $3, $4, $5)
+       RETURNING *;
+
+-- name: UpdateNote :exec
+UPDATE notes
+   SET highlight_id = $1,
+       page_from = $2,
+       page_to = $3,
+       content = $4,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $5;
+
+-- name: DeleteNote :exec
+DELETE FROM notes WHERE id = $1;
+
+-- name: SearchNotes :many
+SELECT notes.*, books.title book_title, isbn
+  FROM notes, books
+ WHERE books.id = notes.book_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+   AND notes.content ILIKE '%' || sqlc.arg(query)::text || '%' ESCAPE '\'
+ ORDER BY notes.updated_at DESC;
+
+-- name: Ping :exec
+SELECT 1;
+
+-- name: Users :many
+SELECT users.*, (SELECT count(*) FROM books WHERE books.user_id = users.id AND books.deleted_at IS NULL) books_count
+  FROM users
+ ORDER BY users.id;
+
+-- name: PromoteUser :exec
+UPDATE users SET admin = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: UserBooks :many
+SELECT * FROM books WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at, id;
+
+-- name: BookImages :many
+SELECT image FROM books WHERE image IS NOT NULL AND length(image) > 0;
+
+-- name: HighlightImages :many
+SELECT image FROM highlights WHERE image IS NOT NULL AND length(image) > 0;
+
+-- name: GoogleCoverIDs :many
+SELECT google_books_id FROM books WHERE google_books_id IS NOT NULL AND image IS NULL
+ UNION
+SELECT google_books_id FROM wishes WHERE google_books_id IS NOT NULL;
@@ -32,6 +32,97 @@ CREATE TABLE public.ar_internal_metadata (
 );
 
 
+--
+-- Name: audit_logs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.audit_logs (
+    id bigint NOT NULL,
+    actor_id bigint,
+    book_id bigint NOT NULL,
+    entity character varying NOT NULL,
+    entity_id bigint NOT NULL,
+    action character varying NOT NULL,
+    changes jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    user_id bigint NOT NULL,
+    book_title character varying DEFAULT ''::character varying NOT NULL
+);
+
+
+--
+-- Name: audit_logs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.audit_logs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: audit_logs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.audit_logs_id_seq OWNED BY public.audit_logs.id;
+
+
+--
+-- Name: authors; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.authors (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    name character varying NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+
+--
+-- Name: authors_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.authors_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: authors_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.authors_id_seq OWNED BY public.authors.id;
+
+
+--
+-- Name: book_authors; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.book_authors (
+    book_id bigint NOT NULL,
+    author_id bigint NOT NULL,
+    role character varying DEFAULT 'author'::character varying NOT NULL,
+    "position" integer DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: book_tags; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.book_tags (
+    book_id bigint NOT NULL,
+    tag_id bigint NOT NULL
+);
+
+
 --
 -- Name: books; Type: TABLE; Schema: public; Owner: -
 --
@@ -44,14 +135,18 @@ CREATE TABLE public.books (
     isbn character varying(13) NOT NULL,
     created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
     updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    shelf_id bigint,
     user_id bigint NOT NULL,
     google_books_id character varying,
     subtitle character varying NOT NULL,
     description character varying NOT NULL,
     page_count integer NOT NULL,
     publisher character varying NOT NULL,
-    page_read integer DEFAULT 0 NOT NULL
+    page_read integer DEFAULT 0 NOT NULL,
+    work_id bigint NOT NULL,
+    rating smallint,
+    review text DEFAULT ''::text NOT NULL,
+    deleted_at timestamp(6) without time zone,
+    CONSTRAINT books_rating_check CHECK (((rating >= 1) AND (rating <= 10)))
 );
 
 
@@ -75,25 +170,24 @@ ALTER SEQUENCE public.books_id_seq OWNED BY public.books.id;
 
 
 --
--- Name: highlights; Type: TABLE; Schema: public; Owner: -
+-- Name: bulk_add_books; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.highlights (
+CREATE TABLE public.bulk_add_books (
     id bigint NOT NULL,
-    book_id bigint NOT NULL,
-    page integer NOT NULL,
-    content character varying NOT NULL,
-    image character varying,
-    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+    bulk_add_id bigint NOT NULL,
+    isbn character varying(13) NOT NULL,
+    status character varying DEFAULT 'waiting'::character varying NOT NULL,
+    title character varying DEFAULT ''::character varying NOT NULL,
+    error character varying DEFAULT ''::character varying NOT NULL
 );
 
 
 --
--- Name: highlights_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+-- Name: bulk_add_books_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE SEQUENCE public.highlights_id_seq
+CREATE SEQUENCE public.bulk_add_books_id_seq
     START WITH 1
     INCREMENT BY 1
     NO MINVALUE
@@ -102,40 +196,67 @@ CREATE SEQUENCE public.highlights_id_seq
 
 
 --
--- Name: highlights_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+-- Name: bulk_add_books_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER SEQUENCE public.highlights_id_seq OWNED BY public.highlights.id;
+ALTER SEQUENCE public.bulk_add_books_id_seq OWNED BY public.bulk_add_books.id;
 
 
 --
--- Name: schema_migrations; Type: TABLE; Schema: public; Owner: -
+-- Name: bulk_adds; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.schema_migrations (
-    version character varying NOT NULL
+CREATE TABLE public.bulk_adds (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    shelf_id bigint,
+    status character varying DEFAULT 'running'::character varying NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
 
 --
--- Name: shelves; Type: TABLE; Schema: public; Owner: -
+-- Name: bulk_adds_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.shelves (
+CREATE SEQUENCE public.bulk_adds_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: bulk_adds_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.bulk_adds_id_seq OWNED BY public.bulk_adds.id;
+
+
+--
+-- Name: copies; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.copies (
     id bigint NOT NULL,
-    name character varying NOT NULL,
+    book_id bigint NOT NULL,
+    shelf_id bigint,
+    condition character varying DEFAULT ''::character varying NOT NULL,
+    acquired_at date,
+    lent_to character varying,
+    lent_at timestamp(6) without time zone,
     created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    user_id bigint NOT NULL,
-    "position" integer NOT NULL
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
 
 --
--- Name: shelves_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+-- Name: copies_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE SEQUENCE public.shelves_id_seq
+CREATE SEQUENCE public.copies_id_seq
     START WITH 1
     INCREMENT BY 1
     NO MINVALUE
@@ -144,41 +265,33 @@ CREATE SEQUENCE public.shelves_id_seq
 
 
 --
--- Name: shelves_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+-- Name: copies_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER SEQUENCE public.shelves_id_seq OWNED BY public.shelves.id;
+ALTER SEQUENCE public.copies_id_seq OWNED BY public.copies.id;
 
 
 --
--- Name: users; Type: TABLE; Schema: public; Owner: -
+-- Name: highlights; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TABLE public.users (
+CREATE TABLE public.highlights (
     id bigint NOT NULL,
-    name character varying,
-    email character varying,
+    book_id bigint NOT NULL,
+    page integer NOT NULL,
+    content character varying NOT NULL,
     image character varying,
     created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
     updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
-    slug character varying NOT NULL,
-    description text,
-    facebook character varying,
-    twitter character varying,
-    linkedin character varying,
-    instagram character varying,
-    phone character varying,
-    whatsapp character varying,
-    telegram character varying,
-    amazon_associates_id character varying
+    deleted_at timestamp(6) without time zone
 );
 
 
 --
--- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+-- Name: highlights_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE SEQUENCE public.users_id_seq
+CREATE SEQUENCE public.highlights_id_seq
     START WITH 1
     INCREMENT BY 1
     NO MINVALUE
@@ -187,159 +300,932 @@ CREATE SEQUENCE public.users_id_seq
 
 
 --
--- Name: users_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+-- Name: highlights_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+ALTER SEQUENCE public.highlights_id_seq OWNED BY public.highlights.id;
 
 
 --
--- Name: books id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: metadata_jobs; Type: TABLE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.books ALTER COLUMN id SET DEFAULT nextval('public.books_id_seq'::regclass);
+CREATE TABLE public.metadata_jobs (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    status character varying DEFAULT 'running'::character varying NOT NULL,
+    last_book_id bigint DEFAULT 0 NOT NULL,
+    processed integer DEFAULT 0 NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: highlights id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: metadata_jobs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.highlights ALTER COLUMN id SET DEFAULT nextval('public.highlights_id_seq'::regclass);
+CREATE SEQUENCE public.metadata_jobs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: shelves id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: metadata_jobs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.shelves ALTER COLUMN id SET DEFAULT nextval('public.shelves_id_seq'::regclass);
+ALTER SEQUENCE public.metadata_jobs_id_seq OWNED BY public.metadata_jobs.id;
 
 
 --
--- Name: users id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: metadata_lookups; Type: TABLE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+CREATE TABLE public.metadata_lookups (
+    id bigint NOT NULL,
+    provider character varying NOT NULL,
+    query character varying NOT NULL,
+    results jsonb NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: ar_internal_metadata ar_internal_metadata_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: metadata_lookups_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.ar_internal_metadata
-    ADD CONSTRAINT ar_internal_metadata_pkey PRIMARY KEY (key);
+CREATE SEQUENCE public.metadata_lookups_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: books books_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: metadata_lookups_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.books
-    ADD CONSTRAINT books_pkey PRIMARY KEY (id);
+ALTER SEQUENCE public.metadata_lookups_id_seq OWNED BY public.metadata_lookups.id;
 
 
 --
--- Name: highlights highlights_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: metadata_suggestions; Type: TABLE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.highlights
-    ADD CONSTRAINT highlights_pkey PRIMARY KEY (id);
+CREATE TABLE public.metadata_suggestions (
+    book_id bigint NOT NULL,
+    metadata jsonb NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: notes; Type: TABLE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.schema_migrations
-    ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
+CREATE TABLE public.notes (
+    id bigint NOT NULL,
+    book_id bigint NOT NULL,
+    highlight_id bigint,
+    page_from integer,
+    page_to integer,
+    content text NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: shelves shelves_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: notes_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.shelves
-    ADD CONSTRAINT shelves_pkey PRIMARY KEY (id);
+CREATE SEQUENCE public.notes_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: notes_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER SEQUENCE public.notes_id_seq OWNED BY public.notes.id;
 
 
 --
--- Name: index_books_on_shelf_id; Type: INDEX; Schema: public; Owner: -
+-- Name: schema_migrations; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE INDEX index_books_on_shelf_id ON public.books USING btree (shelf_id);
+CREATE TABLE public.schema_migrations (
+    version character varying NOT NULL
+);
 
 
 --
--- Name: index_books_on_user_id; Type: INDEX; Schema: public; Owner: -
+-- Name: series; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE INDEX index_books_on_user_id ON public.books USING btree (user_id);
+CREATE TABLE public.series (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    name character varying NOT NULL,
+    volumes integer,
+    google_series_id character varying,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: index_books_on_user_id_and_isbn; Type: INDEX; Schema: public; Owner: -
+-- Name: series_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE UNIQUE INDEX index_books_on_user_id_and_isbn ON public.books USING btree (user_id, isbn);
+CREATE SEQUENCE public.series_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: index_highlights_on_book_id; Type: INDEX; Schema: public; Owner: -
+-- Name: series_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-CREATE INDEX index_highlights_on_book_id ON public.highlights USING btree (book_id);
+ALTER SEQUENCE public.series_id_seq OWNED BY public.series.id;
 
 
 --
--- Name: index_shelves_on_user_id; Type: INDEX; Schema: public; Owner: -
+-- Name: shelves; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE INDEX index_shelves_on_user_id ON public.shelves USING btree (user_id);
+CREATE TABLE public.shelves (
+    id bigint NOT NULL,
+    name character varying NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    user_id bigint NOT NULL,
+    "position" integer NOT NULL,
+    deleted_at timestamp(6) without time zone
+);
 
 
 --
--- Name: index_users_on_email; Type: INDEX; Schema: public; Owner: -
+-- Name: shelves_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-CREATE UNIQUE INDEX index_users_on_email ON public.users USING btree (email);
+CREATE SEQUENCE public.shelves_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: index_users_on_slug; Type: INDEX; Schema: public; Owner: -
+-- Name: shelves_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-CREATE UNIQUE INDEX index_users_on_slug ON public.users USING btree (slug);
+ALTER SEQUENCE public.shelves_id_seq OWNED BY public.shelves.id;
 
 
 --
--- Name: highlights fk_rails_198ee9796d; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: tags; Type: TABLE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.highlights
-    ADD CONSTRAINT fk_rails_198ee9796d FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+CREATE TABLE public.tags (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    name character varying NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
 
 
 --
--- Name: books fk_rails_5e29c313c6; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: tags_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.books
-    ADD CONSTRAINT fk_rails_5e29c313c6 FOREIGN KEY (shelf_id) REFERENCES public.shelves(id) ON DELETE SET NULL;
+CREATE SEQUENCE public.tags_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
 
 
 --
--- Name: shelves fk_rails_6b65d5b892; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: tags_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.shelves
-    ADD CONSTRAINT fk_rails_6b65d5b892 FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+ALTER SEQUENCE public.tags_id_seq OWNED BY public.tags.id;
+
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    name character varying,
+    email character varying,
+    image character varying,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    slug character varying NOT NULL,
+    description text,
+    facebook character varying,
+    twitter character varying,
+    linkedin character varying,
+    instagram character varying,
+    phone character varying,
+    whatsapp character varying,
+    telegram character varying,
+    amazon_associates_id character varying,
+    admin boolean DEFAULT false NOT NULL
+);
+
+
+--
+-- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.users_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: users_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+
+--
+-- Name: wishes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.wishes (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    isbn character varying DEFAULT ''::character varying NOT NULL,
+    title character varying NOT NULL,
+    subtitle character varying DEFAULT ''::character varying NOT NULL,
+    author character varying DEFAULT ''::character varying NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    publisher character varying DEFAULT ''::character varying NOT NULL,
+    page_count integer DEFAULT 0 NOT NULL,
+    google_books_id character varying,
+    series_name character varying DEFAULT ''::character varying NOT NULL,
+    series_position integer,
+    priority smallint DEFAULT 1 NOT NULL,
+    note text DEFAULT ''::text NOT NULL,
+    source character varying DEFAULT ''::character varying NOT NULL,
+    reserved_by character varying,
+    reserved_at timestamp(6) without time zone,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    reserver_id bigint,
+    reserver_token character varying
+);
+
+
+--
+-- Name: wishes_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.wishes_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: wishes_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.wishes_id_seq OWNED BY public.wishes.id;
+
+
+--
+-- Name: works; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.works (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    title character varying NOT NULL,
+    created_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp(6) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    series_id bigint,
+    series_position integer
+);
+
+
+--
+-- Name: works_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.works_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: works_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.works_id_seq OWNED BY public.works.id;
+
+
+--
+-- Name: audit_logs id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs ALTER COLUMN id SET DEFAULT nextval('public.audit_logs_id_seq'::regclass);
+
+
+--
+-- Name: authors id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.authors ALTER COLUMN id SET DEFAULT nextval('public.authors_id_seq'::regclass);
+
+
+--
+-- Name: books id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.books ALTER COLUMN id SET DEFAULT nextval('public.books_id_seq'::regclass);
+
+
+--
+-- Name: bulk_add_books id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_add_books ALTER COLUMN id SET DEFAULT nextval('public.bulk_add_books_id_seq'::regclass);
+
+
+--
+-- Name: bulk_adds id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_adds ALTER COLUMN id SET DEFAULT nextval('public.bulk_adds_id_seq'::regclass);
+
+
+--
+-- Name: copies id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.copies ALTER COLUMN id SET DEFAULT nextval('public.copies_id_seq'::regclass);
+
+
+--
+-- Name: highlights id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.highlights ALTER COLUMN id SET DEFAULT nextval('public.highlights_id_seq'::regclass);
+
+
+--
+-- Name: metadata_jobs id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_jobs ALTER COLUMN id SET DEFAULT nextval('public.metadata_jobs_id_seq'::regclass);
+
+
+--
+-- Name: metadata_lookups id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_lookups ALTER COLUMN id SET DEFAULT nextval('public.metadata_lookups_id_seq'::regclass);
+
+
+--
+-- Name: notes id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.notes ALTER COLUMN id SET DEFAULT nextval('public.notes_id_seq'::regclass);
+
+
+--
+-- Name: series id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.series ALTER COLUMN id SET DEFAULT nextval('public.series_id_seq'::regclass);
+
+
+--
+-- Name: shelves id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.shelves ALTER COLUMN id SET DEFAULT nextval('public.shelves_id_seq'::regclass);
+
+
+--
+-- Name: tags id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.tags ALTER COLUMN id SET DEFAULT nextval('public.tags_id_seq'::regclass);
+
+
+--
+-- Name: users id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+
+--
+-- Name: wishes id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.wishes ALTER COLUMN id SET DEFAULT nextval('public.wishes_id_seq'::regclass);
+
+
+--
+-- Name: works id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.works ALTER COLUMN id SET DEFAULT nextval('public.works_id_seq'::regclass);
+
+
+--
+-- Name: ar_internal_metadata ar_internal_metadata_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.ar_internal_metadata
+    ADD CONSTRAINT ar_internal_metadata_pkey PRIMARY KEY (key);
+
+
+--
+-- Name: audit_logs audit_logs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs
+    ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: authors authors_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.authors
+    ADD CONSTRAINT authors_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: book_authors book_authors_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_authors
+    ADD CONSTRAINT book_authors_pkey PRIMARY KEY (book_id, author_id, role);
+
+
+--
+-- Name: book_tags book_tags_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_tags
+    ADD CONSTRAINT book_tags_pkey PRIMARY KEY (book_id, tag_id);
+
+
+--
+-- Name: books books_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: bulk_add_books bulk_add_books_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_add_books
+    ADD CONSTRAINT bulk_add_books_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: bulk_adds bulk_adds_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_adds
+    ADD CONSTRAINT bulk_adds_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: copies copies_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.copies
+    ADD CONSTRAINT copies_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: highlights highlights_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.highlights
+    ADD CONSTRAINT highlights_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: metadata_jobs metadata_jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_jobs
+    ADD CONSTRAINT metadata_jobs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: metadata_lookups metadata_lookups_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_lookups
+    ADD CONSTRAINT metadata_lookups_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: metadata_suggestions metadata_suggestions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_suggestions
+    ADD CONSTRAINT metadata_suggestions_pkey PRIMARY KEY (book_id);
+
+
+--
+-- Name: notes notes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.notes
+    ADD CONSTRAINT notes_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.schema_migrations
+    ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
+
+
+--
+-- Name: series series_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.series
+    ADD CONSTRAINT series_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: shelves shelves_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.shelves
+    ADD CONSTRAINT shelves_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: tags tags_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: wishes wishes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.wishes
+    ADD CONSTRAINT wishes_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: works works_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.works
+    ADD CONSTRAINT works_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: index_audit_logs_on_book_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_audit_logs_on_book_id ON public.audit_logs USING btree (book_id);
+
+
+--
+-- Name: index_audit_logs_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_audit_logs_on_user_id ON public.audit_logs USING btree (user_id);
+
+
+--
+-- Name: index_authors_on_user_id_and_name; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_authors_on_user_id_and_name ON public.authors USING btree (user_id, name);
+
+
+--
+-- Name: index_book_authors_on_author_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_book_authors_on_author_id ON public.book_authors USING btree (author_id);
+
+
+--
+-- Name: index_book_tags_on_tag_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_book_tags_on_tag_id ON public.book_tags USING btree (tag_id);
+
+
+--
+-- Name: index_books_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_books_on_user_id ON public.books USING btree (user_id);
+
+
+--
+-- Name: index_books_on_user_id_and_isbn; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_books_on_user_id_and_isbn ON public.books USING btree (user_id, isbn) WHERE (deleted_at IS NULL);
+
+
+--
+-- Name: index_books_on_work_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_books_on_work_id ON public.books USING btree (work_id);
+
+
+--
+-- Name: index_bulk_add_books_on_bulk_add_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_bulk_add_books_on_bulk_add_id ON public.bulk_add_books USING btree (bulk_add_id);
+
+
+--
+-- Name: index_bulk_adds_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_bulk_adds_on_user_id ON public.bulk_adds USING btree (user_id);
+
+
+--
+-- Name: index_copies_on_book_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_copies_on_book_id ON public.copies USING btree (book_id);
+
+
+--
+-- Name: index_copies_on_shelf_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_copies_on_shelf_id ON public.copies USING btree (shelf_id);
+
+
+--
+-- Name: index_highlights_on_book_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_highlights_on_book_id ON public.highlights USING btree (book_id);
+
+
+--
+-- Name: index_metadata_jobs_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_metadata_jobs_on_user_id ON public.metadata_jobs USING btree (user_id);
+
+
+--
+-- Name: index_metadata_lookups_on_provider_and_query; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_metadata_lookups_on_provider_and_query ON public.metadata_lookups USING btree (provider, query);
+
+
+--
+-- Name: index_notes_on_book_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_notes_on_book_id ON public.notes USING btree (book_id);
+
+
+--
+-- Name: index_notes_on_highlight_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_notes_on_highlight_id ON public.notes USING btree (highlight_id);
+
+
+--
+-- Name: index_series_on_user_id_and_name; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_series_on_user_id_and_name ON public.series USING btree (user_id, name);
+
+
+--
+-- Name: index_shelves_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_shelves_on_user_id ON public.shelves USING btree (user_id);
+
+
+--
+-- Name: index_tags_on_user_id_and_name; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_tags_on_user_id_and_name ON public.tags USING btree (user_id, name);
+
+
+--
+-- Name: index_users_on_email; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_users_on_email ON public.users USING btree (email);
+
+
+--
+-- Name: index_users_on_slug; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX index_users_on_slug ON public.users USING btree (slug);
+
+
+--
+-- Name: index_wishes_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_wishes_on_user_id ON public.wishes USING btree (user_id);
+
+
+--
+-- Name: index_works_on_series_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_works_on_series_id ON public.works USING btree (series_id);
+
+
+--
+-- Name: index_works_on_user_id; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX index_works_on_user_id ON public.works USING btree (user_id);
+
+
+--
+-- Name: audit_logs audit_logs_actor_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs
+    ADD CONSTRAINT audit_logs_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id) ON DELETE SET NULL;
+
+
+--
+-- Name: audit_logs audit_logs_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_logs
+    ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: authors authors_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.authors
+    ADD CONSTRAINT authors_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: book_authors book_authors_author_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_authors
+    ADD CONSTRAINT book_authors_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.authors(id) ON DELETE CASCADE;
+
+
+--
+-- Name: book_authors book_authors_book_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_authors
+    ADD CONSTRAINT book_authors_book_id_fkey FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: book_tags book_tags_book_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_tags
+    ADD CONSTRAINT book_tags_book_id_fkey FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: book_tags book_tags_tag_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.book_tags
+    ADD CONSTRAINT book_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES public.tags(id) ON DELETE CASCADE;
+
+
+--
+-- Name: books books_work_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_work_id_fkey FOREIGN KEY (work_id) REFERENCES public.works(id) ON DELETE CASCADE;
+
+
+--
+-- Name: bulk_add_books bulk_add_books_bulk_add_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_add_books
+    ADD CONSTRAINT bulk_add_books_bulk_add_id_fkey FOREIGN KEY (bulk_add_id) REFERENCES public.bulk_adds(id) ON DELETE CASCADE;
+
+
+--
+-- Name: bulk_adds bulk_adds_shelf_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_adds
+    ADD CONSTRAINT bulk_adds_shelf_id_fkey FOREIGN KEY (shelf_id) REFERENCES public.shelves(id) ON DELETE SET NULL;
+
+
+--
+-- Name: bulk_adds bulk_adds_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.bulk_adds
+    ADD CONSTRAINT bulk_adds_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: copies copies_book_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.copies
+    ADD CONSTRAINT copies_book_id_fkey FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: copies copies_shelf_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.copies
+    ADD CONSTRAINT copies_shelf_id_fkey FOREIGN KEY (shelf_id) REFERENCES public.shelves(id) ON DELETE SET NULL;
+
+
+--
+-- Name: highlights fk_rails_198ee9796d; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.highlights
+    ADD CONSTRAINT fk_rails_198ee9796d FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: shelves fk_rails_6b65d5b892; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.shelves
+    ADD CONSTRAINT fk_rails_6b65d5b892 FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
 
 --
@@ -350,6 +1236,86 @@ ALTER TABLE ONLY public.books
     ADD CONSTRAINT fk_rails_bc582ddd02 FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: metadata_jobs metadata_jobs_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_jobs
+    ADD CONSTRAINT metadata_jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: metadata_suggestions metadata_suggestions_book_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.metadata_suggestions
+    ADD CONSTRAINT metadata_suggestions_book_id_fkey FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: notes notes_book_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.notes
+    ADD CONSTRAINT notes_book_id_fkey FOREIGN KEY (book_id) REFERENCES public.books(id) ON DELETE CASCADE;
+
+
+--
+-- Name: notes notes_highlight_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.notes
+    ADD CONSTRAINT notes_highlight_id_fkey FOREIGN KEY (highlight_id) REFERENCES public.highlights(id) ON DELETE SET NULL;
+
+
+--
+-- Name: series series_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.series
+    ADD CONSTRAINT series_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: tags tags_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: wishes wishes_reserver_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.wishes
+    ADD CONSTRAINT wishes_reserver_id_fkey FOREIGN KEY (reserver_id) REFERENCES public.users(id) ON DELETE SET NULL;
+
+
+--
+-- Name: wishes wishes_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.wishes
+    ADD CONSTRAINT wishes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: works works_series_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.works
+    ADD CONSTRAINT works_series_id_fkey FOREIGN KEY (series_id) REFERENCES public.series(id) ON DELETE SET NULL;
+
+
+--
+-- Name: works works_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.works
+    ADD CONSTRAINT works_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
 --
 -- PostgreSQL database dump complete
 --
@@ -400,6 +1366,22 @@ INSERT INTO public.schema_migrations VALUES ('20220205001018');
 INSERT INTO public.schema_migrations VALUES ('20220205192708');
 INSERT INTO public.schema_migrations VALUES ('20220205194930');
 INSERT INTO public.schema_migrations VALUES ('20220218225900');
+INSERT INTO public.schema_migrations VALUES ('20221019100000');
+INSERT INTO public.schema_migrations VALUES ('20221019110000');
+INSERT INTO public.schema_migrations VALUES ('20221019120000');
+INSERT INTO public.schema_migrations VALUES ('20221019130000');
+INSERT INTO public.schema_migrations VALUES ('20221019140000');
+INSERT INTO public.schema_migrations VALUES ('20221019150000');
+INSERT INTO public.schema_migrations VALUES ('20221019160000');
+INSERT INTO public.schema_migrations VALUES ('20221019170000');
+INSERT INTO public.schema_migrations VALUES ('20221019180000');
+INSERT INTO public.schema_migrations VALUES ('20221019190000');
+INSERT INTO public.schema_migrations VALUES ('20221019200000');
+INSERT INTO public.schema_migrations VALUES ('20221019210000');
+INSERT INTO public.schema_migrations VALUES ('20221019220000');
+INSERT INTO public.schema_migrations VALUES ('20221019230000');
+INSERT INTO public.schema_migrations VALUES ('20221020000000');
+INSERT INTO public.schema_migrations VALUES ('20221020010000');
 
 
 --
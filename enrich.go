@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// MetadataChange is a book field that a metadata provider has a different
+// value for
+type MetadataChange struct {
+	Field    string
+	Label    string
+	Current  string
+	Proposed string
+}
+
+// MergeMetadata combines the results found for an ISBN in one book, each field
+// is taken from the first provider that has a value for it
+func MergeMetadata(results []BookMetadata, isbn string) BookMetadata {
+	m := BookMetadata{Isbn: isbn}
+	for _, r := range results {
+		if r.Isbn != isbn {
+			continue
+		}
+
+		if m.Title == "" {
+			m.Title = r.Title
+		}
+		if m.Subtitle == "" {
+			m.Subtitle = r.Subtitle
+		}
+		if m.Author == "" {
+			m.Author = r.Author
+		}
+		if m.Description == "" {
+			m.Description = r.Description
+		}
+		if m.Publisher == "" {
+			m.Publisher = r.Publisher
+		}
+		if m.PageCount == 0 {
+			m.PageCount = r.PageCount
+		}
+		if m.GoogleBooksID == "" {
+			m.GoogleBooksID = r.GoogleBooksID
+		}
+	}
+
+	return m
+}
+
+// MetadataChanges lists the fields the proposed metadata would change, empty
+// proposed values never clear a field
+func MetadataChanges(book BookByIsbnAndUserRow, m BookMetadata) []MetadataChange {
+	fields := []MetadataChange{
+		{"title", "Title", book.Title, m.Title},
+		{"subtitle", "Subtitle", book.Subtitle, m.Subtitle},
+		{"author", "Author", book.Author, m.Author},
+		{"description", "Description", book.Description, m.Description},
+		{"publisher", "Publisher", book.Publisher, m.Publisher},
+		{"page_count", "Page count", fmt.Sprint(book.PageCount), fmt.Sprint(m.PageCount)},
+		{"google_books_id", "Google Books ID", book.GoogleBooksID.String, m.GoogleBooksID},
+	}
+
+	changes := []MetadataChange{}
+	for _, f := range fields {
+		if f.Proposed != "" && f.Proposed != "0" && f.Proposed != f.Current {
+			changes = append(changes, f)
+		}
+	}
+
+	return changes
+}
+
+// ProposedMetadata returns the metadata suggested by the bulk job for the book
+// or looks it up from the providers
+func ProposedMetadata(ctx context.Context, book BookByIsbnAndUserRow) (BookMetadata, error) {
+	var m BookMetadata
+
+	suggestion, err := Q.MetadataSuggestion(ctx, book.ID)
+	if err == nil {
+		err = json.Unmarshal(suggestion.Metadata, &m)
+		return m, err
+	}
+	if err != sql.ErrNoRows {
+		return m, err
+	}
+
+	return MergeMetadata(LookupMetadata(ctx, Q, book.Isbn), book.Isbn), nil
+}
+
+// AcceptMetadata returns the book update params and google books ID after
+// taking the accepted fields from the metadata, fields the metadata doesn't
+// propose changing are ignored so they can't be cleared
+func AcceptMetadata(book BookByIsbnAndUserRow, m BookMetadata, accepted []string) (UpdateBookParams, sql.NullString) {
+	proposed := map[string]bool{}
+	for _, c := range MetadataChanges(book, m) {
+		proposed[c.Field] = true
+	}
+
+	params := UpdateBookParams{
+		Title:       book.Title,
+		Author:      book.Author,
+		Subtitle:    book.Subtitle,
+		Description: book.Description,
+		Publisher:   book.Publisher,
+		PageCount:   book.PageCount,
+		PageRead:    book.PageRead,
+		ID:          book.ID,
+	}
+	googleBooksID := book.GoogleBooksID
+
+	for _, field := range accepted {
+		if !proposed[field] {
+			continue
+		}
+
+		switch field {
+		case "title":
+			params.Title = m.Title
+		case "subtitle":
+			params.Subtitle = m.Subtitle
+		case "author":
+			params.Author = m.Author
+		case "description":
+			params.Description = m.Description
+		case "publisher":
+			params.Publisher = m.Publisher
+		case "page_count":
+			params.PageCount = m.PageCount
+		case "google_books_id":
+			googleBooksID = NullString(m.GoogleBooksID)
+		}
+	}
+
+	return params, googleBooksID
+}
+
+// ApplyMetadata updates the book with the accepted metadata and removes the
+// book suggestion
+func ApplyMetadata(ctx context.Context, q *Queries, book BookByIsbnAndUserRow, params UpdateBookParams, googleBooksID sql.NullString) error {
+	if err := q.UpdateBook(ctx, params); err != nil {
+		return err
+	}
+
+	if params.Author != book.Author {
+		if err := SetBookAuthors(ctx, q, book.UserID, book.ID, params.Author); err != nil {
+			return err
+		}
+	}
+
+	if googleBooksID != book.GoogleBooksID {
+		err := q.UpdateBookGoogleBooksID(ctx, UpdateBookGoogleBooksIDParams{
+			GoogleBooksID: googleBooksID,
+			ID:            book.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return q.DeleteMetadataSuggestion(ctx, book.ID)
+}
+
+var enriching sync.Map
+
+// EnrichLibrary looks up metadata for the user books that miss some of it and
+// saves the changes as suggestions for the owner to review. progress is saved
+// after each book so the job continues where it stopped after a restart.
+func EnrichLibrary(job MetadataJob) {
+	if _, running := enriching.LoadOrStore(job.UserID, true); running {
+		return
+	}
+	defer enriching.Delete(job.UserID)
+
+	ctx := context.Background()
+	for {
+		next, err := Q.NextBookToEnrich(ctx, NextBookToEnrichParams{
+			UserID: job.UserID,
+			ID:     job.LastBookID,
+		})
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			log.Printf("Metadata job %d stopped: %s", job.ID, err)
+			return
+		}
+
+		if err = enrichBook(ctx, job.UserID, next.Isbn); err != nil {
+			log.Printf("Metadata job %d failed for book %d: %s", job.ID, next.ID, err)
+		}
+
+		job.LastBookID = next.ID
+		err = Q.MetadataJobProgress(ctx, MetadataJobProgressParams{
+			LastBookID: job.LastBookID,
+			ID:         job.ID,
+		})
+		if err != nil {
+			log.Printf("Metadata job %d stopped: %s", job.ID, err)
+			return
+		}
+	}
+
+	if err := Q.FinishMetadataJob(ctx, job.ID); err != nil {
+		log.Printf("Metadata job %d can't be finished: %s", job.ID, err)
+	}
+}
+
+func enrichBook(ctx context.Context, userID int64, isbn string) error {
+	book, err := Q.BookByIsbnAndUser(ctx, BookByIsbnAndUserParams{
+		UserID: userID,
+		Isbn:   isbn,
+	})
+	if err != nil {
+		return err
+	}
+
+	m := MergeMetadata(LookupMetadata(ctx, Q, isbn), isbn)
+	if len(MetadataChanges(book, m)) == 0 {
+		return nil
+	}
+
+	metadata, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return Q.SaveMetadataSuggestion(ctx, SaveMetadataSuggestionParams{
+		BookID:   book.ID,
+		Metadata: metadata,
+	})
+}
+
+// ResumeMetadataJobs continues the jobs that were running when the server
+// stopped
+func ResumeMetadataJobs() {
+	jobs, err := Q.RunningMetadataJobs(context.Background())
+	if err != nil {
+		log.Printf("Can't resume metadata jobs: %s", err)
+		return
+	}
+
+	for _, job := range jobs {
+		go EnrichLibrary(job)
+	}
+}
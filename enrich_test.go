@@ -0,0 +1,35 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestAcceptMetadata(t *testing.T) {
+	book := BookByIsbnAndUserRow{
+		ID:            1,
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		Description:   "Set on the desert planet Arrakis",
+		Publisher:     "Chilton Books",
+		PageCount:     412,
+		GoogleBooksID: sql.NullString{String: "old", Valid: true},
+	}
+	m := BookMetadata{Title: "Dune", Publisher: "Ace", PageCount: 896}
+
+	// description and google books id aren't proposed, title is the same
+	params, googleBooksID := AcceptMetadata(book, m, []string{"title", "description", "publisher", "google_books_id"})
+
+	if params.Publisher != "Ace" {
+		t.Errorf("expected the proposed publisher, got %q", params.Publisher)
+	}
+	if params.Description != book.Description {
+		t.Errorf("expected the description to be kept, got %q", params.Description)
+	}
+	if params.PageCount != book.PageCount {
+		t.Errorf("expected the page count that wasn't accepted to be kept, got %d", params.PageCount)
+	}
+	if googleBooksID != book.GoogleBooksID {
+		t.Errorf("expected the google books id to be kept, got %+v", googleBooksID)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Export is what the export command writes as JSON, ratings are in stars
+type Export struct {
+	User       string       `json:"user"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Books      []ExportBook `json:"books"`
+}
+
+type ExportBook struct {
+	Isbn        string            `json:"isbn"`
+	Title       string            `json:"title"`
+	Subtitle    string            `json:"subtitle,omitempty"`
+	Author      string            `json:"author"`
+	Publisher   string            `json:"publisher,omitempty"`
+	Description string            `json:"description,omitempty"`
+	PageCount   int32             `json:"page_count"`
+	PageRead    int32             `json:"page_read"`
+	Rating      float64           `json:"rating,omitempty"`
+	Review      string            `json:"review,omitempty"`
+	Shelves     []string          `json:"shelves"`
+	Tags        []string          `json:"tags"`
+	Highlights  []ExportHighlight `json:"highlights"`
+	Notes       []ExportNote      `json:"notes"`
+	AddedAt     time.Time         `json:"added_at"`
+}
+
+type ExportHighlight struct {
+	Page    int32  `json:"page"`
+	Content string `json:"content"`
+}
+
+type ExportNote struct {
+	PageFrom      int32  `json:"page_from,omitempty"`
+	PageTo        int32  `json:"page_to,omitempty"`
+	HighlightPage int32  `json:"highlight_page,omitempty"`
+	Content       string `json:"content"`
+}
+
+// ExportUser writes the user books that aren't in the trash as JSON to w
+func ExportUser(ctx context.Context, w io.Writer, user User) error {
+	books, err := Q.UserBooks(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	export := Export{User: user.Slug, ExportedAt: time.Now().UTC(), Books: []ExportBook{}}
+	for _, b := range books {
+		book := ExportBook{
+			Isbn:        b.Isbn,
+			Title:       b.Title,
+			Subtitle:    b.Subtitle,
+			Author:      b.Author,
+			Publisher:   b.Publisher,
+			Description: b.Description,
+			PageCount:   b.PageCount,
+			PageRead:    b.PageRead,
+			Review:      b.Review,
+			Shelves:     []string{},
+			Tags:        []string{},
+			Highlights:  []ExportHighlight{},
+			Notes:       []ExportNote{},
+			AddedAt:     b.CreatedAt,
+		}
+		if b.Rating.Valid {
+			book.Rating = float64(b.Rating.Int16) / 2
+		}
+
+		copies, err := Q.BookCopies(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range copies {
+			if c.ShelfName.Valid {
+				book.Shelves = append(book.Shelves, c.ShelfName.String)
+			}
+		}
+
+		tags, err := Q.BookTags(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		for _, t := range tags {
+			book.Tags = append(book.Tags, t.Name)
+		}
+
+		highlights, err := Q.Highlights(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		for _, h := range highlights {
+			book.Highlights = append(book.Highlights, ExportHighlight{Page: h.Page, Content: h.Content})
+		}
+
+		notes, err := Q.BookNotes(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		for _, n := range notes {
+			book.Notes = append(book.Notes, ExportNote{
+				PageFrom:      n.PageFrom.Int32,
+				PageTo:        n.PageTo.Int32,
+				HighlightPage: n.HighlightPage.Int32,
+				Content:       n.Content,
+			})
+		}
+
+		export.Books = append(export.Books, book)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
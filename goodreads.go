@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Goodreads exclusive shelves say what the user did with the book, other
+// Goodreads shelves are imported as tags
+var GOODREADS_EXCLUSIVE_SHELVES = []string{"read", "currently-reading", "to-read"}
+
+var goodreadsLineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// ImportResult counts the imported books and says why the others were skipped
+type ImportResult struct {
+	Imported int
+	Skipped  []string
+}
+
+// ImportGoodreads adds the books of a Goodreads library export CSV to the user
+// library without shelves. books the user has or that aren't valid are skipped
+func ImportGoodreads(ctx context.Context, in io.Reader, user User) (ImportResult, error) {
+	result := ImportResult{}
+	r := csv.NewReader(in)
+	header, err := r.Read()
+	if err != nil {
+		return result, fmt.Errorf("Goodreads CSV: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"Title", "Author", "ISBN", "ISBN13"} {
+		if _, ok := columns[name]; !ok {
+			return result, fmt.Errorf("Goodreads CSV: %s column is missing", name)
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("Goodreads CSV: %w", err)
+		}
+
+		row := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if skip, err := importGoodreadsBook(ctx, user, row); err != nil {
+			return result, err
+		} else if len(skip) > 0 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %s", row("Title"), skip))
+		} else {
+			result.Imported++
+		}
+	}
+}
+
+// importGoodreadsBook adds the book in the row or returns why it was skipped
+func importGoodreadsBook(ctx context.Context, user User, row func(string) string) (string, error) {
+	isbn := goodreadsISBN(row("ISBN13"))
+	if len(isbn) == 0 {
+		isbn = ISBN10To13(goodreadsISBN(row("ISBN")))
+	}
+	if len(isbn) == 0 {
+		return "it has no ISBN", nil
+	}
+
+	authors := row("Author")
+	if additional := row("Additional Authors"); len(additional) > 0 {
+		authors += ", " + additional
+	}
+
+	params := NewBookParams{
+		Title:     row("Title"),
+		Isbn:      isbn,
+		Author:    authors,
+		Publisher: row("Publisher"),
+		PageCount: atoi32(row("Number of Pages")),
+		UserID:    user.ID,
+	}
+	if row("Exclusive Shelf") == "read" {
+		params.PageRead = params.PageCount
+	}
+
+	tags := []string{}
+	for _, shelf := range strings.Split(row("Bookshelves"), ",") {
+		shelf = strings.TrimSpace(shelf)
+		exclusive := false
+		for _, s := range GOODREADS_EXCLUSIVE_SHELVES {
+			exclusive = exclusive || s == shelf
+		}
+		if !exclusive {
+			tags = append(tags, shelf)
+		}
+	}
+
+	review := UpdateBookReviewParams{
+		Review: strings.TrimSpace(goodreadsLineBreak.ReplaceAllString(row("My Review"), "\n")),
+	}
+	if stars, err := strconv.Atoi(row("My Rating")); err == nil && stars > 0 {
+		review.Rating = sql.NullInt16{Int16: int16(stars * 2), Valid: true}
+	}
+
+	errors := params.Validate()
+	ValidateTags(strings.Join(tags, ","), "tags", "Tags", errors)
+	for k, v := range review.Validate() {
+		errors[k] = append(errors[k], v...)
+	}
+	if len(errors) > 0 {
+		return errors.Join(), nil
+	}
+
+	_, err := Q.BookByIsbnAndUser(ctx, BookByIsbnAndUserParams{UserID: user.ID, Isbn: isbn})
+	if err == nil {
+		return "it's already in the library", nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	return "", Transaction(ctx, func(q *Queries) error {
+		book, err := AddBook(ctx, q, params, strings.Join(tags, ","), sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+
+		if !review.Rating.Valid && len(review.Review) == 0 {
+			return nil
+		}
+
+		review.ID = book.ID
+		return AuditBook(ctx, q, user.ID, book.ID, "update", func() error {
+			return q.UpdateBookReview(ctx, review)
+		})
+	})
+}
+
+// goodreadsISBN removes the ="..." Goodreads wraps ISBNs with so spreadsheets
+// keep them as text
+func goodreadsISBN(s string) string {
+	return strings.Trim(s, `="`)
+}
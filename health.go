@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Build information, set when building with
+// -ldflags "-X main.VERSION=... -X main.COMMIT=... -X main.BUILD_TIME=..."
+var (
+	VERSION    = "dev"
+	COMMIT     = ""
+	BUILD_TIME = ""
+)
+
+const READY_TIMEOUT = 2 * time.Second
+
+// ReadyCheck returns an error when the server can't serve requests yet
+type ReadyCheck func(context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadyCheck
+}
+
+var readyChecks = []namedCheck{
+	{"database", func(ctx context.Context) error { return Q.Ping(ctx) }},
+	{"templates", func(context.Context) error {
+		if templates == nil || templates.Lookup("layout") == nil {
+			return fmt.Errorf("views aren't compiled")
+		}
+		return nil
+	}},
+}
+
+// READY_CHECK adds a check /readyz runs, checks run in the order they're added
+func READY_CHECK(name string, check ReadyCheck) {
+	for _, c := range readyChecks {
+		if c.name == name {
+			log.Fatalf("Ready check: %s has been defined already", c.name)
+		}
+	}
+
+	readyChecks = append(readyChecks, namedCheck{name, check})
+}
+
+// accessWrite is the W_OK mode of access(2)
+const accessWrite = 0x2
+
+// WritableDir checks the directory exists and the server user can write to
+// it, nothing is created in it as it may be served publicly
+func WritableDir(dir string) ReadyCheck {
+	return func(context.Context) error {
+		stat, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+
+		if !stat.IsDir() {
+			return fmt.Errorf("%s isn't a directory", dir)
+		}
+
+		if err = syscall.Access(dir, accessWrite); err != nil {
+			return fmt.Errorf("%s isn't writable: %w", dir, err)
+		}
+
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// HealthzHandler responds as long as the server is handling requests
+func HealthzHandler(w Response, r Request) Output {
+	return func(w Response, r Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// ReadyzHandler runs the ready checks and responds with 503 when any of them
+// fails. failures are logged, the response only says which checks failed
+func ReadyzHandler(w Response, r Request) Output {
+	return func(w Response, r Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), READY_TIMEOUT)
+		defer cancel()
+
+		status := http.StatusOK
+		checks := map[string]string{}
+		for _, c := range readyChecks {
+			if err := c.check(ctx); err != nil {
+				LogError(r, fmt.Errorf("Ready check %s failed: %w", c.name, err))
+				status = http.StatusServiceUnavailable
+				checks[c.name] = "fail"
+				continue
+			}
+
+			checks[c.name] = "ok"
+		}
+
+		result := "ok"
+		if status != http.StatusOK {
+			result = "unavailable"
+		}
+
+		writeJSON(w, status, map[string]interface{}{"status": result, "checks": checks})
+	}
+}
+
+func VersionHandler(w Response, r Request) Output {
+	return func(w Response, r Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"version":    VERSION,
+			"commit":     COMMIT,
+			"build_time": BUILD_TIME,
+			"go":         runtime.Version(),
+		})
+	}
+}
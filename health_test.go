@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWritableDir(t *testing.T) {
+	dir := t.TempDir()
+	file := path.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WritableDir(dir)(context.Background()); err != nil {
+		t.Errorf("expected %s to be writable, got %s", dir, err)
+	}
+
+	for _, p := range []string{path.Join(dir, "missing"), file} {
+		if err := WritableDir(p)(context.Background()); err == nil {
+			t.Errorf("expected %s to fail the check", p)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the check to leave the directory as it is, it has %d entries", len(entries))
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	checks := readyChecks
+	defer func() { readyChecks = checks }()
+
+	readyChecks = []namedCheck{
+		{"up", func(context.Context) error { return nil }},
+		{"down", func(context.Context) error { return fmt.Errorf("dial tcp 10.0.0.5:5432: connection refused") }},
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	ReadyzHandler(w, r)(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+
+	var body struct {
+		Status string
+		Checks map[string]string
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Status != "unavailable" || body.Checks["up"] != "ok" || body.Checks["down"] != "fail" {
+		t.Errorf("expected the down check to fail without details, got %+v", body)
+	}
+}
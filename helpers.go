@@ -17,6 +17,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/image/draw"
@@ -24,9 +25,12 @@ import (
 
 func Helpers() {
 	HELPER("partial", func(path string, data interface{}) (template.HTML, error) {
-		return template.HTML(partial(path, data)), nil
+		out, err := partial(path, data)
+		return template.HTML(out), err
 	})
 
+	HELPER("url_for", url_for)
+
 	HELPER("meta_property", func(meta map[string]string, name string) template.HTML {
 		if meta == nil {
 			return ""
@@ -73,8 +77,42 @@ func Helpers() {
 		return template.HTML(strings.ReplaceAll(template.HTMLEscapeString(str), "\n", "<br/>")), nil
 	})
 
-	HELPER("shelf_books", func(shelfID int64) ([]ShelfBooksRow, error) {
-		return Q.ShelfBooks(context.Background(), sql.NullInt64{Valid: true, Int64: shelfID})
+	HELPER("markdown", Markdown)
+
+	HELPER("shelf_books", func(shelfID int64, sort string) ([]ShelfBooksRow, error) {
+		return Q.ShelfBooks(context.Background(), ShelfBooksParams{
+			ShelfID:  sql.NullInt64{Valid: true, Int64: shelfID},
+			TopRated: sort == "rating",
+		})
+	})
+
+	HELPER("shelf_rating", ShelfRating)
+	HELPER("stars", Stars)
+	HELPER("rating_options", RatingOptions)
+
+	HELPER("user_series", func(userID int64) ([]UserSeriesRow, error) {
+		return Q.UserSeries(context.Background(), userID)
+	})
+
+	HELPER("user_tags", func(userID int64) ([]UserTagsRow, error) {
+		return Q.UserTags(context.Background(), userID)
+	})
+
+	// tag_size scales a tag books count relative to the most used tag to a
+	// bulma size from 6 (least used) to 3 (most used)
+	HELPER("tag_size", func(count int64, tags []UserTagsRow) int64 {
+		var max int64 = 1
+		for _, t := range tags {
+			if t.BooksCount > max {
+				max = t.BooksCount
+			}
+		}
+
+		if max == 1 {
+			return 6
+		}
+
+		return 6 - (count-1)*3/(max-1)
 	})
 
 	HELPER("has_field", func(v interface{}, name string) bool {
@@ -109,6 +147,10 @@ func Helpers() {
 		return rv.Index(rv.Len() - 1)
 	})
 
+	HELPER("inc", func(i int) int {
+		return i + 1
+	})
+
 	HELPER("books_count", func(u int64) int64 {
 		c, _ := Q.BooksCount(context.Background(), u)
 		return c
@@ -166,9 +208,10 @@ func book_cover(image, google_books_id string) string {
 		return "/books/image/" + image
 	}
 
+	// google covers are served through the server until they're mirrored so
+	// visitors don't request them from google
 	if len(google_books_id) > 0 {
-		const googleBookURL = "https://books.google.com/books/content?id=%s&printsec=frontcover&img=1&zoom=1"
-		return fmt.Sprintf(googleBookURL, google_books_id)
+		return "/books/google/" + google_books_id
 	}
 
 	return "/default_book"
@@ -190,7 +233,7 @@ func can(who *User, do string, what interface{}) bool {
 
 	case User:
 		switch do {
-		case "create_book", "list_shelves", "edit", "create_shelf", "show_shelves":
+		case "create_book", "list_shelves", "edit", "create_shelf", "show_shelves", "create_wish", "enrich":
 			return who != nil && who.ID == w.ID
 		default:
 			log.Fatal(err)
@@ -206,8 +249,35 @@ func can(who *User, do string, what interface{}) bool {
 
 	case BookByIsbnAndUserRow:
 		switch do {
-		case "edit", "highlight", "create_highlight", "edit_highlight", "delete", "delete_highlight":
+		case "edit", "highlight", "create_highlight", "edit_highlight", "delete", "delete_highlight", "review", "note":
+			return who != nil && who.ID == w.UserID
+		default:
+			log.Fatal(err)
+		}
+
+	case Author:
+		switch do {
+		case "edit", "merge":
+			return who != nil && who.ID == w.UserID
+		default:
+			log.Fatal(err)
+		}
+
+	case Series:
+		switch do {
+		case "edit":
+			return who != nil && who.ID == w.UserID
+		default:
+			log.Fatal(err)
+		}
+
+	case Wish:
+		switch do {
+		case "edit", "delete", "buy":
 			return who != nil && who.ID == w.UserID
+		case "reserve":
+			// the owner shouldn't know what was reserved for them
+			return who == nil || who.ID != w.UserID
 		default:
 			log.Fatal(err)
 		}
@@ -250,10 +320,14 @@ func UploadImage(in io.Reader, p string, w, h int) (string, error) {
 		return "", err
 	}
 
-	err = ImageResize(in, out, w, h)
+	start := time.Now()
+	size := &countingReader{Reader: in}
+	err = ImageResize(size, out, w, h)
 	if err != nil {
 		return "", err
 	}
 
+	imageUploadSize.Observe(float64(size.n))
+	imageProcessingDuration.Since(start)
 	return name, nil
 }
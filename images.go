@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// IMAGES_GC_MIN_AGE keeps new files, uploads are saved before the book or
+// highlight that uses them
+const IMAGES_GC_MIN_AGE = time.Hour
+
+// UnusedImages lists the files in the images directories no book or highlight
+// uses. trashed books and highlights still use their images until they're
+// purged so they can be restored. cached google covers are kept while a book
+// without an image or a wish uses them
+func UnusedImages(ctx context.Context) ([]string, error) {
+	used := map[string]bool{}
+
+	covers, err := Q.BookImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range covers {
+		used[path.Join(BOOK_COVER_PATH, v.String)] = true
+	}
+
+	highlights, err := Q.HighlightImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range highlights {
+		used[path.Join(HIGHLIGHT_IMAGE_PATH, v.String)] = true
+	}
+
+	googleCovers, err := Q.GoogleCoverIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range googleCovers {
+		used[path.Join(GOOGLE_COVER_CACHE_PATH, v.String)] = true
+	}
+
+	unused := []string{}
+	for _, dir := range []string{BOOK_COVER_PATH, HIGHLIGHT_IMAGE_PATH, GOOGLE_COVER_CACHE_PATH} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			p := path.Join(dir, e.Name())
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") || used[p] {
+				continue
+			}
+
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			if time.Since(info.ModTime()) > IMAGES_GC_MIN_AGE {
+				unused = append(unused, p)
+			}
+		}
+	}
+
+	return unused, nil
+}
+
+// RemoveUnusedImages removes the files UnusedImages lists and returns the
+// removed ones
+func RemoveUnusedImages(ctx context.Context) ([]string, error) {
+	unused, err := UnusedImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, p := range unused {
+		if err := os.Remove(p); err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, p)
+	}
+
+	return removed, nil
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
@@ -17,9 +22,29 @@ import (
 const (
 	BOOK_COVER_PATH      = "public/books/image"
 	HIGHLIGHT_IMAGE_PATH = "public/highlights/image"
+
+	META_DESCRIPTION_LENGTH = 200
 )
 
 func main() {
+	Commands()
+
+	if err := LoadConfig(os.Args[1:]); err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	setLogFormat(CONFIG.LogFormat)
+
+	if err := RunCommand(context.Background(), CONFIG.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Serve registers the routes and background jobs then serves HTTP until the
+// server is stopped
+func Serve(ctx context.Context, args []string) error {
 	google := &oauth2.Config{
 		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
 		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
@@ -35,18 +60,18 @@ func main() {
 	GET("/", func(w Response, r Request) Output {
 		user := current_user(r)
 		if user != nil {
-			return Redirect(fmt.Sprintf("/users/%s", user.Slug))
+			return Redirect(url_for("user", user.Slug))
 		}
 
 		return Render("wide_layout", "index", Locals{"csrf": CSRF(r)})
-	})
+	}).Name("root")
 
 	GET("/privacy", func(w Response, r Request) Output {
 		return Render("layout", "privacy", Locals{
 			"current_user": current_user(r),
 			"csrf":         CSRF(r),
 		})
-	})
+	}).Name("privacy")
 
 	POST("/auth/google", func(w Response, r Request) Output {
 		origin := r.FormValue("origin")
@@ -63,7 +88,7 @@ func main() {
 		}
 
 		return Redirect(google.AuthCodeURL(state))
-	})
+	}).Name("auth_google")
 
 	GET("/auth/google/callback", func(w Response, r Request) Output {
 		state := SESSION(r).Values["state"]
@@ -120,14 +145,26 @@ func main() {
 		}
 
 		return Redirect(origin)
-	})
+	}).Name("auth_google_callback")
 
 	GET("/logout", func(w Response, r Request) Output {
 		s := SESSION(r)
 		s.Values = map[interface{}]interface{}{}
 		s.Save(r, w)
-		return Redirect("/")
-	})
+		return Redirect(url_for("root"))
+	}).Name("logout")
+
+	GET("/books/google/{id}", func(w Response, r Request) Output {
+		p, err := GoogleCover(r.Context(), VARS(r)["id"])
+		if err != nil {
+			return Redirect("/default_book")
+		}
+
+		return func(w Response, r Request) {
+			w.Header().Set("Cache-Control", "public, max-age=2592000")
+			http.ServeFile(w, r, p)
+		}
+	}).Name("google_book_cover")
 
 	GET("/users/{user}", func(w Response, r Request) Output {
 		vars := VARS(r)
@@ -142,9 +179,15 @@ func main() {
 			"csrf":         CSRF(r),
 			"current_user": current_user(r),
 			"user":         user,
+			"sort":         r.URL.Query().Get("sort"),
+			"selecting":    r.URL.Query().Get("select") == "1" && can(current_user(r), "edit", user),
+			"back":         r.URL.EscapedPath(),
 		}
 
-		unshelved_books, err := Q.UserUnshelvedBooks(r.Context(), user.ID)
+		unshelved_books, err := Q.UserUnshelvedBooks(r.Context(), UserUnshelvedBooksParams{
+			UserID:   user.ID,
+			TopRated: data["sort"] == "rating",
+		})
 		if err != nil {
 			return InternalServerError(err)
 		}
@@ -153,9 +196,17 @@ func main() {
 		}
 
 		data["shelves"], err = Q.Shelves(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		data["tags"], err = Q.UserTags(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
 
 		return Render("layout", "users/show", data)
-	})
+	}).Name("user")
 
 	GET("/users/{user}/edit", func(w Response, r Request) Output {
 		actor := current_user(r)
@@ -176,7 +227,7 @@ func main() {
 			"errors":       ValidationErrors{},
 			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("edit_user")
 
 	POST("/users/{user}", func(w Response, r Request) Output {
 		actor := current_user(r)
@@ -227,7 +278,7 @@ func main() {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s", user.Slug))
+		return Redirect(url_for("user", user.Slug))
 	}, loggedinMiddleware)
 
 	GET("/users/{user}/books/new", func(w Response, r Request) Output {
@@ -243,13 +294,39 @@ func main() {
 			return Unauthorized
 		}
 
+		// lookup lists books found by the metadata providers, picking one of
+		// them fills the form with its metadata
+		book := NewBookParams{}
+		series := SeriesForm{}
+		lookup := r.URL.Query().Get("lookup")
+		results := []BookMetadata{}
+		if len(lookup) > 0 {
+			results = LookupMetadata(r.Context(), Q, lookup)
+
+			pick := r.URL.Query().Get("pick")
+			if i := int(atoi32(pick)); len(pick) > 0 && i >= 0 && i < len(results) {
+				book = results[i].NewBookParams()
+				series = results[i].SeriesForm()
+				results = nil
+			}
+
+			if q, isbn := MetadataQuery(lookup); isbn && len(q) == 13 && len(book.Isbn) == 0 {
+				book.Isbn = q
+			}
+		}
+
 		return Render("layout", "books/new", Locals{
 			"current_user": actor,
 			"user":         user,
+			"book":         book,
+			"lookup":       lookup,
+			"results":      results,
+			"series":       series,
+			"tags":         "",
 			"errors":       ValidationErrors{},
 			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("new_book")
 
 	POST("/users/{user}/books", func(w Response, r Request) Output {
 		actor := current_user(r)
@@ -278,6 +355,10 @@ func main() {
 			UserID:        user.ID,
 		}
 		errors := params.Validate()
+		series := SeriesFormFromRequest(r)
+		series.Validate(errors)
+		tags := r.FormValue("tags")
+		ValidateTags(tags, "tags", "Tags", errors)
 
 		file, _, _ := r.FormFile("image")
 		if file != nil {
@@ -288,6 +369,8 @@ func main() {
 		if len(errors) != 0 {
 			return Render("layout", "books/new", Locals{
 				"book":         params,
+				"series":       series,
+				"tags":         tags,
 				"current_user": actor,
 				"user":         user,
 				"errors":       errors,
@@ -295,7 +378,14 @@ func main() {
 			})
 		}
 
-		book, err := Q.NewBook(r.Context(), params)
+		var book Book
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if book, err = AddBook(r.Context(), q, params, tags, sql.NullInt64{}); err != nil {
+				return err
+			}
+
+			return SetWorkSeries(r.Context(), q, user.ID, book.WorkID, series)
+		})
 		if err != nil {
 			return InternalServerError(err)
 		}
@@ -315,58 +405,76 @@ func main() {
 			}
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, book.Isbn))
-	})
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}).Name("books")
 
-	GET("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
+	POST("/users/{user}/books/scan", func(w Response, r Request) Output {
+		actor := current_user(r)
 		vars := VARS(r)
 
 		user, err := Q.UserBySlug(r.Context(), vars["user"])
 		if err != nil {
-			return InternalServerError(err)
+			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
+		if !can(actor, "create_book", user) {
+			return Unauthorized
 		}
 
-		highlights, err := Q.Highlights(r.Context(), book.ID)
+		r.ParseMultipartForm(MB * 10)
+		errors := ValidationErrors{}
+		isbns := []string{}
+
+		file, _, _ := r.FormFile("barcode")
+		if file == nil {
+			errors.Add("barcode", fmt.Errorf("Barcode photo is required"))
+		} else if codes, err := DecodeEAN13(file); err != nil {
+			errors.Add("barcode", fmt.Errorf("Barcode photo can't be read: %w", err))
+		} else if isbns = ISBNBarcodes(codes); len(isbns) == 0 {
+			errors.Add("barcode", fmt.Errorf("No ISBN barcode found in the photo, try a closer and sharper photo"))
+		}
+
+		if len(errors) != 0 {
+			return Render("layout", "books/new", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         NewBookParams{},
+				"series":       SeriesForm{},
+				"tags":         "",
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		books, err := LookupBooks(r.Context(), user.ID, isbns)
 		if err != nil {
 			return InternalServerError(err)
 		}
 
+		if len(books) == 1 {
+			isbn := books[0].Metadata.Isbn
+			if books[0].Exists {
+				return Redirect(url_for("book", user.Slug, isbn))
+			}
+
+			return Redirect(url_for("new_book", user.Slug) + "?lookup=" + url.QueryEscape(isbn) + "&pick=0")
+		}
+
 		shelves, err := Q.Shelves(r.Context(), user.ID)
 		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Render("layout", "books/show", Locals{
-			"current_user": current_user(r),
+		return Render("layout", "books/scan", Locals{
+			"current_user": actor,
 			"user":         user,
-			"title":        book.Title,
-			"book":         book,
+			"books":        books,
 			"shelves":      shelves,
-			"highlights":   highlights,
 			"csrf":         CSRF(r),
-			"meta": map[string]string{
-				"og:title":       book.Title,
-				"author":         book.Author,
-				"description":    book.Description,
-				"og:description": book.Description,
-				"og:type":        "article",
-				"og:image":       book_cover(book.Image.String, book.GoogleBooksID.String),
-				"twitter:image":  book_cover(book.Image.String, book.GoogleBooksID.String),
-				"twitter:card":   "summary",
-				"twitter:title":  book.Title,
-			},
 		})
-	})
+	}, loggedinMiddleware).Name("scan_books")
 
-	GET("/users/{user}/books/{isbn}/edit", func(w Response, r Request) Output {
+	GET("/users/{user}/books/bulk", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -375,28 +483,34 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
+		if !can(actor, "create_book", user) {
+			return Unauthorized
+		}
+
+		shelves, err := Q.Shelves(r.Context(), user.ID)
 		if err != nil {
-			return NotFound
+			return InternalServerError(err)
 		}
 
-		if !can(actor, "edit", book) {
-			return Unauthorized
+		jobs, err := Q.UserBulkAdds(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
 		}
 
-		return Render("layout", "books/new", Locals{
+		return Render("layout", "books/bulk", Locals{
 			"current_user": actor,
 			"user":         user,
-			"book":         book,
-			"csrf":         CSRF(r),
+			"isbns":        "",
+			"limit":        BULK_ADD_LIMIT,
+			"shelf_id":     int64(0),
+			"shelves":      shelves,
+			"jobs":         jobs,
 			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("bulk_books")
 
-	POST("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
+	POST("/users/{user}/books/bulk", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -405,125 +519,69 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
-		}
-
-		if !can(actor, "edit", book) {
+		if !can(actor, "create_book", user) {
 			return Unauthorized
 		}
 
 		r.ParseMultipartForm(MB * 10)
+		list := strings.Join(r.Form["isbns"], "\n")
+		errors := ValidationErrors{}
 
-		params := UpdateBookParams{
-			Title:       r.FormValue("title"),
-			Author:      r.FormValue("author"),
-			Subtitle:    r.FormValue("subtitle"),
-			Description: r.FormValue("description"),
-			Publisher:   r.FormValue("publisher"),
-			PageCount:   atoi32(r.FormValue("page_count")),
-			PageRead:    atoi32(r.FormValue("page_read")),
-			ID:          book.ID,
-		}
-
-		errors := params.Validate()
-		file, _, _ := r.FormFile("image")
+		file, _, _ := r.FormFile("file")
 		if file != nil {
-			ValidateImage(file, "image", "Image", errors, 600, 600)
-			file.Seek(0, os.SEEK_SET)
+			content, err := io.ReadAll(io.LimitReader(file, MB))
+			if err != nil {
+				return BadRequest
+			}
+			list += "\n" + string(content)
 		}
 
-		if len(errors) > 0 {
-			book.Title = params.Title
-			book.Author = params.Author
-			book.Subtitle = params.Subtitle
-			book.Description = params.Description
-			book.Publisher = params.Publisher
-			book.PageCount = params.PageCount
-			book.PageRead = params.PageRead
-			return Render("layout", "books/new", Locals{
-				"current_user": actor,
-				"user":         user,
-				"book":         book,
-				"csrf":         CSRF(r),
-				"errors":       errors,
-			})
+		isbns := ParseISBNList(list)
+		if len(isbns) == 0 {
+			errors.Add("isbns", fmt.Errorf("ISBNs list can't be empty"))
+		} else if len(isbns) > BULK_ADD_LIMIT {
+			errors.Add("isbns", fmt.Errorf("ISBNs list can't have more than %d ISBNs", BULK_ADD_LIMIT))
 		}
 
-		if err = Q.UpdateBook(r.Context(), params); err != nil {
+		shelves, err := Q.Shelves(r.Context(), user.ID)
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		if file != nil {
-			name, err := UploadImage(file, BOOK_COVER_PATH, 432, 576)
-			if err != nil {
-				return InternalServerError(err)
-			}
-
-			oldname := path.Join(BOOK_COVER_PATH, book.Image.String)
-			if book.Image.Valid && len(book.Image.String) > 0 { // if image is set
-				if _, err = os.Stat(oldname); err == nil { // and it exists
-					os.Remove(oldname) // delete it
-				}
-			}
-
-			err = Q.UpdateBookImage(r.Context(), UpdateBookImageParams{
-				Image: NullString(name),
-				ID:    book.ID,
+		shelfID := sql.NullInt64{}
+		if id := atoi64(r.FormValue("shelf_id")); id != 0 {
+			shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+				UserID: user.ID,
+				ID:     id,
 			})
 			if err != nil {
-				return InternalServerError(err)
+				return BadRequest
 			}
+			shelfID = sql.NullInt64{Int64: shelf.ID, Valid: true}
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, vars["isbn"]))
-	}, loggedinMiddleware)
-
-	DELETE("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
-		actor := current_user(r)
-		vars := VARS(r)
-
-		user, err := Q.UserBySlug(r.Context(), vars["user"])
-		if err != nil {
-			return NotFound
-		}
-
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
-		}
-
-		if !can(actor, "delete", book) {
-			return Unauthorized
+		if len(errors) != 0 {
+			return Render("layout", "books/bulk", Locals{
+				"current_user": actor,
+				"user":         user,
+				"isbns":        list,
+				"limit":        BULK_ADD_LIMIT,
+				"shelf_id":     shelfID.Int64,
+				"shelves":      shelves,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
 		}
 
-		images, err := Q.HighlightsWithImages(r.Context(), book.ID)
+		job, err := StartBulkAdd(r.Context(), user.ID, isbns, shelfID)
 		if err != nil {
 			return InternalServerError(err)
 		}
-		for _, v := range images {
-			os.Remove(path.Join(HIGHLIGHT_IMAGE_PATH, v.String))
-		}
-
-		if book.Image.Valid && len(book.Image.String) > 0 {
-			os.Remove(path.Join(BOOK_COVER_PATH, book.Image.String))
-		}
-
-		if err = Q.DeleteBook(r.Context(), book.ID); err != nil {
-			return InternalServerError(err)
-		}
 
-		return Redirect(fmt.Sprintf("/users/%s", user.Slug))
+		return Redirect(url_for("bulk_add", user.Slug, job.ID))
 	}, loggedinMiddleware)
 
-	POST("/users/{user}/books/{isbn}/shelf", func(w Response, r Request) Output {
+	GET("/users/{user}/books/bulk/{id}", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -532,38 +590,32 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
-		}
-
-		if !can(actor, "edit", book) {
+		if !can(actor, "create_book", user) {
 			return Unauthorized
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		job, err := Q.BulkAddByIDAndUser(r.Context(), BulkAddByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
 			UserID: user.ID,
-			ID:     atoi64(r.FormValue("shelf_id")),
 		})
-		if err == nil && !can(actor, "edit", shelf) {
-			return Unauthorized
+		if err != nil {
+			return NotFound
 		}
 
-		err = Q.MoveBookToShelf(r.Context(), MoveBookToShelfParams{
-			ShelfID: sql.NullInt64{Int64: shelf.ID, Valid: err == nil},
-			ID:      book.ID,
-		})
+		books, err := Q.BulkAddBooks(r.Context(), job.ID)
 		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, vars["isbn"]))
-	}, loggedinMiddleware)
+		return Render("layout", "books/bulk_add", Locals{
+			"current_user": actor,
+			"user":         user,
+			"job":          job,
+			"books":        books,
+		})
+	}, loggedinMiddleware).Name("bulk_add")
 
-	POST("/users/{user}/books/{isbn}/complete", func(w Response, r Request) Output {
+	POST("/users/{user}/books/selection", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -572,54 +624,2187 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
-		}
-
-		if !can(actor, "edit", book) {
+		if !can(actor, "edit", user) {
 			return Unauthorized
 		}
 
-		err = Q.CompleteBook(r.Context(), book.ID)
-		if err != nil {
-			return InternalServerError(err)
+		// go back to the listing the books were selected from
+		back := url_for("user", user.Slug)
+		if b := r.FormValue("back"); strings.HasPrefix(b, back+"/") {
+			back = b
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, book.Isbn))
-	}, loggedinMiddleware)
-
-	GET("/users/{user}/shelves", func(w Response, r Request) Output {
-		actor := current_user(r)
-		vars := VARS(r)
-
-		user, err := Q.UserBySlug(r.Context(), vars["user"])
-		if err != nil {
-			return NotFound
-		}
+		// a book can be selected through many of its copies
+		copies := []CopyByIDAndUserRow{}
+		books := []CopyByIDAndUserRow{}
+		selected := map[int64]bool{}
+		for _, id := range r.Form["copies"] {
+			c, err := Q.CopyByIDAndUser(r.Context(), CopyByIDAndUserParams{
+				UserID: user.ID,
+				ID:     atoi64(id),
+			})
+			if err != nil {
+				return NotFound
+			}
 
-		if !can(actor, "show_shelves", user) {
-			return Unauthorized
+			copies = append(copies, c)
+			if !selected[c.BookID] {
+				selected[c.BookID] = true
+				books = append(books, c)
+			}
 		}
 
-		shelves, err := Q.Shelves(r.Context(), user.ID)
+		switch r.FormValue("action") {
+		case "move":
+			// an empty shelf leaves the copies lying around
+			shelfID := sql.NullInt64{}
+			if id := r.FormValue("shelf_id"); id != "" {
+				shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+					UserID: user.ID,
+					ID:     atoi64(id),
+				})
+				if err == sql.ErrNoRows {
+					return BadRequest
+				}
+				if err != nil {
+					return InternalServerError(err)
+				}
+				shelfID = sql.NullInt64{Int64: shelf.ID, Valid: true}
+			}
+
+			err = Transaction(r.Context(), func(q *Queries) error {
+				for _, c := range copies {
+					err := AuditMove(r.Context(), q, actor.ID, c.BookID, c.ID, func() error {
+						return q.MoveCopyToShelf(r.Context(), MoveCopyToShelfParams{
+							ShelfID: shelfID,
+							ID:      c.ID,
+						})
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+		case "complete":
+			err = Transaction(r.Context(), func(q *Queries) error {
+				for _, b := range books {
+					err := AuditBook(r.Context(), q, actor.ID, b.BookID, "update", func() error {
+						return q.CompleteBook(r.Context(), b.BookID)
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+		case "tag":
+			tags := r.FormValue("tags")
+			errors := ValidationErrors{}
+			ValidateTags(tags, "tags", "Tags", errors)
+			if len(errors) > 0 {
+				return BadRequest
+			}
+
+			err = Transaction(r.Context(), func(q *Queries) error {
+				for _, b := range books {
+					err := AuditBook(r.Context(), q, actor.ID, b.BookID, "update", func() error {
+						return AddBookTags(r.Context(), q, user.ID, b.BookID, tags)
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+		case "delete":
+			err = Transaction(r.Context(), func(q *Queries) error {
+				for _, b := range books {
+					err := AuditBook(r.Context(), q, actor.ID, b.BookID, "delete", func() error {
+						return q.TrashBook(r.Context(), b.BookID)
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+		default:
+			return BadRequest
+		}
+
+		return Redirect(back)
+	}, loggedinMiddleware).Name("books_selection")
+
+	GET("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		highlights, err := Q.WorkHighlights(r.Context(), book.WorkID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		shelves, err := Q.Shelves(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		copies, err := Q.BookCopies(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		editions, err := Q.WorkEditions(r.Context(), book.WorkID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		authors, err := Q.BookAuthors(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		tags, err := Q.BookTags(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		// notes are private to the owner
+		notes := []BookNotesRow{}
+		if can(current_user(r), "note", book) {
+			notes, err = Q.BookNotes(r.Context(), book.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		var seriesPrev, seriesNext *SeriesBooksRow
+		if book.SeriesID.Valid {
+			seriesBooks, err := Q.SeriesBooks(r.Context(), book.SeriesID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			seriesPrev, seriesNext = SeriesNeighbours(seriesBooks, book.WorkID)
+		}
+
+		description := book.Description
+		if len(book.Review) > 0 {
+			description = MarkdownText(book.Review, META_DESCRIPTION_LENGTH)
+		}
+
+		// copies on a trashed shelf have no shelf name
+		var shelfID int64
+		for _, c := range copies {
+			if c.ShelfName.Valid {
+				shelfID = c.ShelfID.Int64
+				break
+			}
+		}
+
+		return Render("layout", "books/show", Locals{
+			"current_user": current_user(r),
+			"user":         user,
+			"title":        book.Title,
+			"book":         book,
+			"shelves":      shelves,
+			"shelf_id":     shelfID,
+			"copies":       copies,
+			"editions":     editions,
+			"authors":      authors,
+			"tags":         tags,
+			"series_prev":  seriesPrev,
+			"series_next":  seriesNext,
+			"highlights":   highlights,
+			"notes":        notes,
+			"csrf":         CSRF(r),
+			"meta": map[string]string{
+				"og:title":       book.Title,
+				"author":         book.Author,
+				"description":    description,
+				"og:description": description,
+				"og:type":        "article",
+				"og:image":       book_cover(book.Image.String, book.GoogleBooksID.String),
+				"twitter:image":  book_cover(book.Image.String, book.GoogleBooksID.String),
+				"twitter:card":   "summary",
+				"twitter:title":  book.Title,
+			},
+		})
+	}).Name("book")
+
+	GET("/users/{user}/books/{isbn}/edit", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		tags, err := Q.BookTags(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "books/new", Locals{
+			"current_user": actor,
+			"user":         user,
+			"book":         book,
+			"series": SeriesForm{
+				Name:     book.SeriesName.String,
+				Position: book.SeriesPosition,
+			},
+			"tags":   TagsString(tags),
+			"csrf":   CSRF(r),
+			"errors": ValidationErrors{},
+		})
+	}, loggedinMiddleware).Name("edit_book")
+
+	POST("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		r.ParseMultipartForm(MB * 10)
+
+		params := UpdateBookParams{
+			Title:       r.FormValue("title"),
+			Author:      r.FormValue("author"),
+			Subtitle:    r.FormValue("subtitle"),
+			Description: r.FormValue("description"),
+			Publisher:   r.FormValue("publisher"),
+			PageCount:   atoi32(r.FormValue("page_count")),
+			PageRead:    atoi32(r.FormValue("page_read")),
+			ID:          book.ID,
+		}
+
+		errors := params.Validate()
+		series := SeriesFormFromRequest(r)
+		series.Validate(errors)
+		tags := r.FormValue("tags")
+		ValidateTags(tags, "tags", "Tags", errors)
+
+		file, _, _ := r.FormFile("image")
+		if file != nil {
+			ValidateImage(file, "image", "Image", errors, 600, 600)
+			file.Seek(0, os.SEEK_SET)
+		}
+
+		if len(errors) > 0 {
+			book.Title = params.Title
+			book.Author = params.Author
+			book.Subtitle = params.Subtitle
+			book.Description = params.Description
+			book.Publisher = params.Publisher
+			book.PageCount = params.PageCount
+			book.PageRead = params.PageRead
+			return Render("layout", "books/new", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         book,
+				"series":       series,
+				"tags":         tags,
+				"csrf":         CSRF(r),
+				"errors":       errors,
+			})
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "update", func() error {
+				if err := q.UpdateBook(r.Context(), params); err != nil {
+					return err
+				}
+
+				if err := SetBookAuthors(r.Context(), q, user.ID, book.ID, params.Author); err != nil {
+					return err
+				}
+
+				if err := SetWorkSeries(r.Context(), q, user.ID, book.WorkID, series); err != nil {
+					return err
+				}
+
+				return SetBookTags(r.Context(), q, user.ID, book.ID, tags)
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		if file != nil {
+			name, err := UploadImage(file, BOOK_COVER_PATH, 432, 576)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			oldname := path.Join(BOOK_COVER_PATH, book.Image.String)
+			if book.Image.Valid && len(book.Image.String) > 0 { // if image is set
+				if _, err = os.Stat(oldname); err == nil { // and it exists
+					os.Remove(oldname) // delete it
+				}
+			}
+
+			err = Q.UpdateBookImage(r.Context(), UpdateBookImageParams{
+				Image: NullString(name),
+				ID:    book.ID,
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		return Redirect(url_for("book", user.Slug, vars["isbn"]))
+	}, loggedinMiddleware)
+
+	DELETE("/users/{user}/books/{isbn}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "delete", book) {
+			return Unauthorized
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "delete", func() error {
+				return q.TrashBook(r.Context(), book.ID)
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("user", user.Slug))
+	}, loggedinMiddleware)
+
+	POST("/users/{user}/books/{isbn}/shelf", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		bookCopy, err := Q.CopyByIDAndBook(r.Context(), CopyByIDAndBookParams{
+			ID:     atoi64(r.FormValue("copy_id")),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(r.FormValue("shelf_id")),
+		})
+		if err == nil && !can(actor, "edit", shelf) {
+			return Unauthorized
+		}
+
+		shelfID := sql.NullInt64{Int64: shelf.ID, Valid: err == nil}
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditMove(r.Context(), q, actor.ID, book.ID, bookCopy.ID, func() error {
+				return q.MoveCopyToShelf(r.Context(), MoveCopyToShelfParams{
+					ShelfID: shelfID,
+					ID:      bookCopy.ID,
+				})
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, vars["isbn"]))
+	}, loggedinMiddleware).Name("book_shelf")
+
+	GET("/users/{user}/books/{isbn}/review", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "review", book) {
+			return Unauthorized
+		}
+
+		return Render("layout", "reviews/edit", Locals{
+			"current_user": actor,
+			"user":         user,
+			"book":         book,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("book_review")
+
+	POST("/users/{user}/books/{isbn}/review", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "review", book) {
+			return Unauthorized
+		}
+
+		params := UpdateBookReviewParams{
+			Rating: ParseRating(r.FormValue("rating")),
+			Review: strings.TrimSpace(r.FormValue("review")),
+			ID:     book.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			book.Rating = params.Rating
+			book.Review = params.Review
+			return Render("layout", "reviews/edit", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         book,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "update", func() error {
+				return q.UpdateBookReview(r.Context(), params)
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/books/{isbn}/metadata", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		metadata, err := ProposedMetadata(r.Context(), book)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "books/metadata", Locals{
+			"current_user": actor,
+			"user":         user,
+			"book":         book,
+			"changes":      MetadataChanges(book, metadata),
+			"next":         r.URL.Query().Get("next"),
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("book_metadata")
+
+	POST("/users/{user}/books/{isbn}/metadata", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		metadata, err := ProposedMetadata(r.Context(), book)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		r.ParseForm()
+		params, googleBooksID := AcceptMetadata(book, metadata, r.Form["accept"])
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			return Render("layout", "books/metadata", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         book,
+				"changes":      MetadataChanges(book, metadata),
+				"next":         r.FormValue("next"),
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "update", func() error {
+				return ApplyMetadata(r.Context(), q, book, params, googleBooksID)
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		if r.FormValue("next") == "suggestions" {
+			return Redirect(url_for("metadata", user.Slug))
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware)
+
+	POST("/users/{user}/books/{isbn}/complete", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "update", func() error {
+				return q.CompleteBook(r.Context(), book.ID)
+			})
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("complete_book")
+
+	GET("/users/{user}/books/{isbn}/history", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		logs, err := Q.BookAuditLogs(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		entries, err := AuditEntries(logs)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "books/history", Locals{
+			"current_user": actor,
+			"user":         user,
+			"title":        book.Title,
+			"book":         book,
+			"entries":      entries,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("book_history")
+
+	POST("/users/{user}/books/{isbn}/history/{id}/revert", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		auditLog, err := Q.AuditLogByIDAndBook(r.Context(), AuditLogByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		var changes map[string]AuditChange
+		if err = json.Unmarshal(auditLog.Changes, &changes); err != nil {
+			return InternalServerError(err)
+		}
+
+		field := r.FormValue("field")
+		change, ok := changes[field]
+		if !ok || !Revertible(auditLog.Entity, auditLog.Action, field) {
+			return BadRequest
+		}
+
+		var errors ValidationErrors
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if auditLog.Entity == AUDIT_BOOK {
+				errors, err = RevertBookField(r.Context(), q, actor.ID, book, field, change.Before)
+				return err
+			}
+
+			highlight, err := q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+				ID:     auditLog.EntityID,
+				BookID: book.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			errors, err = RevertHighlightField(r.Context(), q, actor.ID, highlight, field, change.Before)
+			return err
+		})
+		if err == sql.ErrNoRows {
+			return NotFound
+		}
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		if len(errors) > 0 {
+			logs, err := Q.BookAuditLogs(r.Context(), book.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			entries, err := AuditEntries(logs)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			return Render("layout", "books/history", Locals{
+				"current_user": actor,
+				"user":         user,
+				"title":        book.Title,
+				"book":         book,
+				"entries":      entries,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		return Redirect(url_for("book_history", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("revert_book_history")
+
+	POST("/users/{user}/books/{isbn}/work", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		// An empty ISBN splits the edition into a work of its own
+		isbn := r.FormValue("isbn")
+		err = Transaction(r.Context(), func(q *Queries) error {
+			var workID int64
+			if len(isbn) == 0 {
+				work, err := q.NewWork(r.Context(), NewWorkParams{
+					UserID: user.ID,
+					Title:  book.Title,
+				})
+				if err != nil {
+					return err
+				}
+				workID = work.ID
+			} else {
+				edition, err := q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+					UserID: user.ID,
+					Isbn:   isbn,
+				})
+				if err != nil {
+					return err
+				}
+				workID = edition.WorkID
+			}
+
+			err := q.MoveBookToWork(r.Context(), MoveBookToWorkParams{
+				WorkID: workID,
+				ID:     book.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err = q.DeleteOrphanWorks(r.Context(), user.ID); err != nil {
+				return err
+			}
+
+			return q.DeleteOrphanSeries(r.Context(), user.ID)
+		})
+		if err == sql.ErrNoRows {
+			return NotFound
+		}
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("book_work")
+
+	POST("/users/{user}/books/{isbn}/copies", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		if _, err = Q.NewCopy(r.Context(), NewCopyParams{BookID: book.ID}); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("copies")
+
+	GET("/users/{user}/books/{isbn}/copies/{copy}/edit", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		bookCopy, err := Q.CopyByIDAndBook(r.Context(), CopyByIDAndBookParams{
+			ID:     atoi64(vars["copy"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		return Render("layout", "copies/edit", Locals{
+			"current_user": actor,
+			"user":         user,
+			"book":         book,
+			"copy":         bookCopy,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("edit_copy")
+
+	POST("/users/{user}/books/{isbn}/copies/{copy}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		bookCopy, err := Q.CopyByIDAndBook(r.Context(), CopyByIDAndBookParams{
+			ID:     atoi64(vars["copy"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		params := UpdateCopyParams{
+			Condition:  r.FormValue("condition"),
+			AcquiredAt: NullDate(r.FormValue("acquired_at")),
+			LentTo:     NullString(strings.TrimSpace(r.FormValue("lent_to"))),
+			LentAt:     bookCopy.LentAt,
+			ID:         bookCopy.ID,
+		}
+
+		// lending starts when the borrower changes and ends when it's cleared
+		if !params.LentTo.Valid {
+			params.LentAt = sql.NullTime{}
+		} else if params.LentTo != bookCopy.LentTo {
+			params.LentAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			bookCopy.Condition = params.Condition
+			bookCopy.AcquiredAt = params.AcquiredAt
+			bookCopy.LentTo = params.LentTo
+			return Render("layout", "copies/edit", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         book,
+				"copy":         bookCopy,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		if err = Q.UpdateCopy(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("copy")
+
+	DELETE("/users/{user}/books/{isbn}/copies/{copy}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		bookCopy, err := Q.CopyByIDAndBook(r.Context(), CopyByIDAndBookParams{
+			ID:     atoi64(vars["copy"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", book) {
+			return Unauthorized
+		}
+
+		// The last copy goes away with the book itself
+		copies, err := Q.BookCopies(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+		if len(copies) < 2 {
+			return BadRequest
+		}
+
+		if err = Q.DeleteCopy(r.Context(), bookCopy.ID); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/authors", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		authors, err := Q.Authors(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "authors/index", Locals{
+			"current_user": current_user(r),
+			"user":         user,
+			"title":        "Authors",
+			"authors":      authors,
+			"csrf":         CSRF(r),
+		})
+	}).Name("authors")
+
+	GET("/users/{user}/authors/{id}", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		author, err := Q.AuthorByIDAndUser(r.Context(), AuthorByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		books, err := Q.AuthorBooks(r.Context(), author.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		data := Locals{
+			"current_user": current_user(r),
+			"user":         user,
+			"title":        author.Name,
+			"author":       author,
+			"books":        books,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		}
+
+		if can(current_user(r), "merge", author) {
+			if data["authors"], err = Q.Authors(r.Context(), user.ID); err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		return Render("layout", "authors/show", data)
+	}).Name("author")
+
+	POST("/users/{user}/authors/{id}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		author, err := Q.AuthorByIDAndUser(r.Context(), AuthorByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", author) {
+			return Unauthorized
+		}
+
+		params := UpdateAuthorParams{
+			Name: strings.TrimSpace(r.FormValue("name")),
+			ID:   author.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) == 0 {
+			other, err := Q.AuthorByNameAndUser(r.Context(), AuthorByNameAndUserParams{
+				Name:   params.Name,
+				UserID: user.ID,
+			})
+			if err == nil && other.ID != author.ID {
+				errors.Add("name", fmt.Errorf("Another author has the same name, merge it instead"))
+			} else if err != nil && err != sql.ErrNoRows {
+				return InternalServerError(err)
+			}
+		}
+
+		if len(errors) > 0 {
+			books, err := Q.AuthorBooks(r.Context(), author.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			authors, err := Q.Authors(r.Context(), user.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			author.Name = params.Name
+			return Render("layout", "authors/show", Locals{
+				"current_user": actor,
+				"user":         user,
+				"author":       author,
+				"authors":      authors,
+				"books":        books,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if err := q.UpdateAuthor(r.Context(), params); err != nil {
+				return err
+			}
+
+			return q.RefreshAuthorBooks(r.Context(), author.ID)
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("author", user.Slug, author.ID))
+	}, loggedinMiddleware)
+
+	POST("/users/{user}/authors/{id}/merge", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		author, err := Q.AuthorByIDAndUser(r.Context(), AuthorByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		duplicate, err := Q.AuthorByIDAndUser(r.Context(), AuthorByIDAndUserParams{
+			ID:     atoi64(r.FormValue("author_id")),
+			UserID: user.ID,
+		})
+		if err != nil || duplicate.ID == author.ID {
+			return BadRequest
+		}
+
+		if !can(actor, "merge", author) || !can(actor, "merge", duplicate) {
+			return Unauthorized
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			err := q.MergeAuthor(r.Context(), MergeAuthorParams{
+				TargetID:    author.ID,
+				DuplicateID: duplicate.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err = q.DeleteAuthor(r.Context(), duplicate.ID); err != nil {
+				return err
+			}
+
+			return q.RefreshAuthorBooks(r.Context(), author.ID)
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("author", user.Slug, author.ID))
+	}, loggedinMiddleware).Name("merge_author")
+
+	GET("/users/{user}/series", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		series, err := Q.UserSeries(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "series/index", Locals{
+			"current_user": current_user(r),
+			"user":         user,
+			"title":        "Series",
+			"series":       series,
+			"csrf":         CSRF(r),
+		})
+	}).Name("series_index")
+
+	GET("/users/{user}/series/{id}", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		series, err := Q.SeriesByIDAndUser(r.Context(), SeriesByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		books, err := Q.SeriesBooks(r.Context(), sql.NullInt64{Int64: series.ID, Valid: true})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		volumes, unnumbered := SeriesVolumes(books, series.Volumes)
+
+		return Render("layout", "series/show", Locals{
+			"current_user": current_user(r),
+			"user":         user,
+			"title":        series.Name,
+			"series":       series,
+			"volumes":      volumes,
+			"unnumbered":   unnumbered,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}).Name("series")
+
+	POST("/users/{user}/series/{id}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		series, err := Q.SeriesByIDAndUser(r.Context(), SeriesByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", series) {
+			return Unauthorized
+		}
+
+		params := UpdateSeriesParams{
+			Name:    strings.TrimSpace(r.FormValue("name")),
+			Volumes: NullInt32(r.FormValue("volumes")),
+			ID:      series.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) == 0 {
+			other, err := Q.SeriesByNameAndUser(r.Context(), SeriesByNameAndUserParams{
+				Name:   params.Name,
+				UserID: user.ID,
+			})
+			if err == nil && other.ID != series.ID {
+				errors.Add("name", fmt.Errorf("Another series has the same name"))
+			} else if err != nil && err != sql.ErrNoRows {
+				return InternalServerError(err)
+			}
+		}
+
+		if len(errors) > 0 {
+			books, err := Q.SeriesBooks(r.Context(), sql.NullInt64{Int64: series.ID, Valid: true})
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			volumes, unnumbered := SeriesVolumes(books, series.Volumes)
+			series.Name = params.Name
+			series.Volumes = params.Volumes
+			return Render("layout", "series/show", Locals{
+				"current_user": actor,
+				"user":         user,
+				"series":       series,
+				"volumes":      volumes,
+				"unnumbered":   unnumbered,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		if err = Q.UpdateSeries(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("series", user.Slug, series.ID))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/tags/{tag}", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		tag, err := Q.TagByNameAndUser(r.Context(), TagByNameAndUserParams{
+			Name:   vars["tag"],
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		books, err := Q.TagBooks(r.Context(), tag.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		shelves, err := Q.Shelves(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		// Same listing as the profile, limited to the tagged books
+		shelfBooks := map[int64][]TagBooksRow{}
+		for _, b := range books {
+			shelfBooks[b.ShelfID.Int64] = append(shelfBooks[b.ShelfID.Int64], b)
+		}
+
+		return Render("layout", "tags/show", Locals{
+			"current_user":    current_user(r),
+			"user":            user,
+			"title":           tag.Name,
+			"tag":             tag,
+			"shelves":         shelves,
+			"shelf_books":     shelfBooks,
+			"unshelved_books": shelfBooks[0],
+			"selecting":       r.URL.Query().Get("select") == "1" && can(current_user(r), "edit", user),
+			"back":            r.URL.EscapedPath(),
+			"csrf":            CSRF(r),
+		})
+	}).Name("tag")
+
+	GET("/users/{user}/wishlist", func(w Response, r Request) Output {
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wishes, err := Q.UserWishes(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		actor := current_user(r)
+		token := ReservationToken(r)
+		reservations := map[int64]bool{}
+		for _, wish := range wishes {
+			reservations[wish.ID] = ReservedByVisitor(wish, actor, token)
+		}
+
+		return Render("layout", "wishes/index", Locals{
+			"current_user": actor,
+			"user":         user,
+			"title":        user.Name.String + "'s wishlist",
+			"wishes":       wishes,
+			"reservations": reservations,
+			"priorities":   WISH_PRIORITIES,
+			"csrf":         CSRF(r),
+		})
+	}).Name("wishlist")
+
+	GET("/users/{user}/wishlist/new", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "create_wish", user) {
+			return Unauthorized
+		}
+
+		wish := NewWishParams{
+			Title:          r.URL.Query().Get("title"),
+			SeriesName:     r.URL.Query().Get("series"),
+			SeriesPosition: NullInt32(r.URL.Query().Get("series_position")),
+		}
+
+		// same lookup as the new book page, picking a result fills the wish
+		lookup := r.URL.Query().Get("lookup")
+		results := []BookMetadata{}
+		if len(lookup) > 0 {
+			results = LookupMetadata(r.Context(), Q, lookup)
+
+			pick := r.URL.Query().Get("pick")
+			if i := int(atoi32(pick)); len(pick) > 0 && i >= 0 && i < len(results) {
+				wish = results[i].NewWishParams()
+				results = nil
+			}
+		}
+		wish.Priority = 1
+
+		return Render("layout", "wishes/new", Locals{
+			"current_user": actor,
+			"user":         user,
+			"wish":         wish,
+			"lookup":       lookup,
+			"results":      results,
+			"priorities":   WISH_PRIORITIES,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("new_wish")
+
+	POST("/users/{user}/wishlist", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "create_wish", user) {
+			return Unauthorized
+		}
+
+		params := NewWishParams{
+			UserID:         user.ID,
+			Isbn:           strings.TrimSpace(r.FormValue("isbn")),
+			Title:          r.FormValue("title"),
+			Subtitle:       r.FormValue("subtitle"),
+			Author:         r.FormValue("author"),
+			Description:    r.FormValue("description"),
+			Publisher:      r.FormValue("publisher"),
+			PageCount:      atoi32(r.FormValue("page_count")),
+			GoogleBooksID:  NullString(r.FormValue("google_books_id")),
+			SeriesName:     strings.TrimSpace(r.FormValue("series")),
+			SeriesPosition: NullInt32(r.FormValue("series_position")),
+			Priority:       int16(atoi32(r.FormValue("priority"))),
+			Note:           r.FormValue("note"),
+			Source:         r.FormValue("source"),
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			return Render("layout", "wishes/new", Locals{
+				"current_user": actor,
+				"user":         user,
+				"wish":         params,
+				"priorities":   WISH_PRIORITIES,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		if _, err = Q.NewWish(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("wishlist", user.Slug))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/wishlist/{id}/edit", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", wish) {
+			return Unauthorized
+		}
+
+		return Render("layout", "wishes/new", Locals{
+			"current_user": actor,
+			"user":         user,
+			"wish":         wish,
+			"priorities":   WISH_PRIORITIES,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("edit_wish")
+
+	POST("/users/{user}/wishlist/{id}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", wish) {
+			return Unauthorized
+		}
+
+		params := UpdateWishParams{
+			Isbn:           strings.TrimSpace(r.FormValue("isbn")),
+			Title:          r.FormValue("title"),
+			Subtitle:       r.FormValue("subtitle"),
+			Author:         r.FormValue("author"),
+			Description:    r.FormValue("description"),
+			Publisher:      r.FormValue("publisher"),
+			PageCount:      atoi32(r.FormValue("page_count")),
+			GoogleBooksID:  NullString(r.FormValue("google_books_id")),
+			SeriesName:     strings.TrimSpace(r.FormValue("series")),
+			SeriesPosition: NullInt32(r.FormValue("series_position")),
+			Priority:       int16(atoi32(r.FormValue("priority"))),
+			Note:           r.FormValue("note"),
+			Source:         r.FormValue("source"),
+			ID:             wish.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			return Render("layout", "wishes/new", Locals{
+				"current_user": actor,
+				"user":         user,
+				"wish":         params,
+				"priorities":   WISH_PRIORITIES,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		if err = Q.UpdateWish(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("wishlist", user.Slug))
+	}, loggedinMiddleware).Name("wish")
+
+	DELETE("/users/{user}/wishlist/{id}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "delete", wish) {
+			return Unauthorized
+		}
+
+		if err = Q.DeleteWish(r.Context(), wish.ID); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("wishlist", user.Slug))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/wishlist/{id}/bought", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "buy", wish) {
+			return Unauthorized
+		}
+
+		shelves, err := Q.Shelves(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "wishes/bought", Locals{
+			"current_user": actor,
+			"user":         user,
+			"wish":         wish,
+			"shelves":      shelves,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("bought_wish")
+
+	POST("/users/{user}/wishlist/{id}/bought", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "buy", wish) {
+			return Unauthorized
+		}
+
+		wish.Isbn = strings.TrimSpace(r.FormValue("isbn"))
+		wish.Author = r.FormValue("author")
+		params := NewBookParams{
+			Title:         wish.Title,
+			Isbn:          wish.Isbn,
+			Author:        wish.Author,
+			Subtitle:      wish.Subtitle,
+			Description:   wish.Description,
+			Publisher:     wish.Publisher,
+			PageCount:     wish.PageCount,
+			GoogleBooksID: wish.GoogleBooksID,
+			UserID:        user.ID,
+		}
+
+		shelfID := sql.NullInt64{}
+		if id := atoi64(r.FormValue("shelf_id")); id != 0 {
+			shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+				UserID: user.ID,
+				ID:     id,
+			})
+			if err != nil {
+				return BadRequest
+			}
+			shelfID = sql.NullInt64{Int64: shelf.ID, Valid: true}
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			shelves, err := Q.Shelves(r.Context(), user.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			return Render("layout", "wishes/bought", Locals{
+				"current_user": actor,
+				"user":         user,
+				"wish":         wish,
+				"shelves":      shelves,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return BuyWish(r.Context(), q, wish, params, shelfID)
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("book", user.Slug, params.Isbn))
+	}, loggedinMiddleware)
+
+	POST("/users/{user}/wishlist/{id}/reserve", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "reserve", wish) {
+			return Unauthorized
+		}
+
+		name := strings.TrimSpace(r.FormValue("name"))
+		if actor != nil && len(name) == 0 {
+			name = actor.Name.String
+		}
+
+		if len(name) == 0 || len(name) > 100 {
+			return BadRequest
+		}
+
+		token, err := NewReservationToken(w, r)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		params := ReserveWishParams{
+			ReservedBy:    NullString(name),
+			ReserverToken: NullString(token),
+			ID:            wish.ID,
+		}
+		if actor != nil {
+			params.ReserverID = sql.NullInt64{Int64: actor.ID, Valid: true}
+		}
+
+		if err = Q.ReserveWish(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("wishlist", user.Slug))
+	}).Name("reserve_wish")
+
+	DELETE("/users/{user}/wishlist/{id}/reserve", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		wish, err := Q.WishByIDAndUser(r.Context(), WishByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !ReservedByVisitor(wish, actor, ReservationToken(r)) {
+			return Unauthorized
+		}
+
+		if err = Q.UnreserveWish(r.Context(), wish.ID); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("wishlist", user.Slug))
+	})
+
+	GET("/users/{user}/metadata", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "enrich", user) {
+			return Unauthorized
+		}
+
+		job, err := Q.MetadataJobByUser(r.Context(), user.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return InternalServerError(err)
+		}
+
+		books, err := Q.UserMetadataSuggestions(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "metadata/index", Locals{
+			"current_user": actor,
+			"user":         user,
+			"job":          job,
+			"books":        books,
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("metadata")
+
+	POST("/users/{user}/metadata", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "enrich", user) {
+			return Unauthorized
+		}
+
+		job, err := Q.MetadataJobByUser(r.Context(), user.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return InternalServerError(err)
+		}
+
+		if job.Status != "running" {
+			if job, err = Q.StartMetadataJob(r.Context(), user.ID); err != nil {
+				return InternalServerError(err)
+			}
+
+			go EnrichLibrary(job)
+		}
+
+		return Redirect(url_for("metadata", user.Slug))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/shelves", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "show_shelves", user) {
+			return Unauthorized
+		}
+
+		shelves, err := Q.Shelves(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "shelves/index", Locals{
+			"current_user": actor,
+			"user":         user,
+			"shelves":      shelves,
+			"errors":       ValidationErrors{},
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("shelves")
+
+	POST("/users/{user}/shelves", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "create_shelf", user) {
+			return Unauthorized
+		}
+
+		params := NewShelfParams{
+			Name:   r.FormValue("name"),
+			UserID: user.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			shelves, err := Q.Shelves(r.Context(), user.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			return Render("layout", "shelves/index", Locals{
+				"current_user": actor,
+				"user":         user,
+				"shelves":      shelves,
+				"shelf":        params,
+				"csrf":         CSRF(r),
+				"errors":       errors,
+			})
+		}
+
+		if err = Q.NewShelf(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/shelves/{shelf}/edit", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(vars["shelf"]),
+		})
+
+		if !can(actor, "edit", shelf) {
+			return Unauthorized
+		}
+
+		return Render("layout", "shelves/edit", Locals{
+			"current_user": actor,
+			"user":         user,
+			"errors":       ValidationErrors{},
+			"shelf":        shelf,
+			"csrf":         CSRF(r),
+		})
+	}, loggedinMiddleware).Name("edit_shelf")
+
+	POST("/users/{user}/shelves/{shelf}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(vars["shelf"]),
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit", shelf) {
+			return Unauthorized
+		}
+
+		params := UpdateShelfParams{
+			Name: r.FormValue("name"),
+			ID:   shelf.ID,
+		}
+
+		errors := params.Validate()
+		if len(errors) > 0 {
+			return Render("layout", "shelves/edit", Locals{
+				"current_user": actor,
+				"user":         user,
+				"shelf":        params,
+				"csrf":         CSRF(r),
+				"errors":       errors,
+			})
+		}
+
+		if err = Q.UpdateShelf(r.Context(), params); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware).Name("shelf")
+
+	POST("/users/{user}/shelves/{shelf}/up", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(vars["shelf"]),
+		})
 		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "up", shelf) {
+			return Unauthorized
+		}
+
+		if err = Q.MoveShelfUp(r.Context(), shelf.ID); err != nil {
 			return InternalServerError(err)
 		}
 
-		return Render("layout", "shelves/index", Locals{
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware).Name("shelf_up")
+
+	POST("/users/{user}/shelves/{shelf}/down", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(vars["shelf"]),
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "down", shelf) {
+			return Unauthorized
+		}
+
+		if err = Q.MoveShelfDown(r.Context(), shelf.ID); err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware).Name("shelf_down")
+
+	DELETE("/users/{user}/shelves/{shelf}", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+			UserID: user.ID,
+			ID:     atoi64(vars["shelf"]),
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "delete", shelf) {
+			return Unauthorized
+		}
+
+		// the shelf goes to the trash, its books are lying around until it's
+		// restored
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if err := q.RemoveShelf(r.Context(), shelf.ID); err != nil {
+				return err
+			}
+
+			return q.TrashShelf(r.Context(), shelf.ID)
+		})
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware)
+
+	GET("/users/{user}/books/{isbn}/highlights/new", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
+		}
+
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "create_highlight", book) {
+			return Unauthorized
+		}
+
+		return Render("layout", "highlights/new", Locals{
 			"current_user": actor,
+			"book":         book,
 			"user":         user,
-			"shelves":      shelves,
 			"errors":       ValidationErrors{},
 			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("new_highlight")
 
-	POST("/users/{user}/shelves", func(w Response, r Request) Output {
+	POST("/users/{user}/books/{isbn}/highlights", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -628,40 +2813,75 @@ func main() {
 			return NotFound
 		}
 
-		if !can(actor, "create_shelf", user) {
-			return Unauthorized
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
 		}
 
-		params := NewShelfParams{
-			Name:   r.FormValue("name"),
-			UserID: user.ID,
+		if !can(actor, "create_highlight", book) {
+			return Unauthorized
 		}
 
+		r.ParseMultipartForm(MB * 10)
+
+		params := NewHighlightParams{
+			BookID:  book.ID,
+			Page:    atoi32(r.FormValue("page")),
+			Content: r.FormValue("content"),
+		}
 		errors := params.Validate()
-		if len(errors) > 0 {
-			shelves, err := Q.Shelves(r.Context(), user.ID)
-			if err != nil {
-				return InternalServerError(err)
-			}
 
-			return Render("layout", "shelves/index", Locals{
+		file, _, _ := r.FormFile("image")
+		if file != nil {
+			ValidateImage(file, "image", "Image", errors, 1000, 1000)
+			file.Seek(0, os.SEEK_SET)
+		}
+
+		if len(errors) > 0 {
+			return Render("layout", "highlights/new", Locals{
 				"current_user": actor,
+				"book":         book,
 				"user":         user,
-				"shelves":      shelves,
-				"shelf":        params,
-				"csrf":         CSRF(r),
+				"highlight":    params,
 				"errors":       errors,
+				"csrf":         CSRF(r),
 			})
 		}
 
-		if err = Q.NewShelf(r.Context(), params); err != nil {
+		var highlight Highlight
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if highlight, err = q.NewHighlight(r.Context(), params); err != nil {
+				return err
+			}
+
+			return AuditHighlight(r.Context(), q, actor.ID, "create", nil, &highlight)
+		})
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/shelves", user.Slug))
-	}, loggedinMiddleware)
+		if file != nil {
+			name, err := UploadImage(file, HIGHLIGHT_IMAGE_PATH, 600, 600)
+			if err != nil {
+				return InternalServerError(err)
+			}
 
-	GET("/users/{user}/shelves/{shelf}/edit", func(w Response, r Request) Output {
+			err = Q.UpdateHighlightImage(r.Context(), UpdateHighlightImageParams{
+				Image: NullString(name),
+				ID:    highlight.ID,
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("highlights")
+
+	GET("/users/{user}/books/{isbn}/highlights/{id}/edit", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -670,25 +2890,37 @@ func main() {
 			return NotFound
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
 			UserID: user.ID,
-			ID:     atoi64(vars["shelf"]),
+			Isbn:   vars["isbn"],
 		})
+		if err != nil {
+			return NotFound
+		}
 
-		if !can(actor, "edit", shelf) {
+		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		if !can(actor, "edit_highlight", book) {
 			return Unauthorized
 		}
 
-		return Render("layout", "shelves/edit", Locals{
+		return Render("layout", "highlights/new", Locals{
 			"current_user": actor,
+			"book":         book,
 			"user":         user,
+			"highlight":    highlight,
 			"errors":       ValidationErrors{},
-			"shelf":        shelf,
 			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("edit_highlight")
 
-	POST("/users/{user}/shelves/{shelf}", func(w Response, r Request) Output {
+	POST("/users/{user}/books/{isbn}/highlights/{id}", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -697,42 +2929,85 @@ func main() {
 			return NotFound
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
 			UserID: user.ID,
-			ID:     atoi64(vars["shelf"]),
+			Isbn:   vars["isbn"],
 		})
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "edit", shelf) {
-			return Unauthorized
+		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
 		}
 
-		params := UpdateShelfParams{
-			Name: r.FormValue("name"),
-			ID:   shelf.ID,
+		if !can(actor, "edit_highlight", book) {
+			return Unauthorized
 		}
 
+		params := UpdateHighlightParams{
+			Page:    atoi32(r.FormValue("page")),
+			Content: r.FormValue("content"),
+			ID:      highlight.ID,
+		}
 		errors := params.Validate()
+
+		file, _, _ := r.FormFile("image")
+		if file != nil {
+			ValidateImage(file, "image", "Image", errors, 1000, 1000)
+			file.Seek(0, os.SEEK_SET)
+		}
+
 		if len(errors) > 0 {
-			return Render("layout", "shelves/edit", Locals{
+			highlight.Content = params.Content
+			highlight.Page = params.Page
+			return Render("layout", "highlights/new", Locals{
 				"current_user": actor,
 				"user":         user,
-				"shelf":        params,
-				"csrf":         CSRF(r),
+				"book":         book,
+				"highlight":    highlight,
 				"errors":       errors,
+				"csrf":         CSRF(r),
 			})
 		}
 
-		if err = Q.UpdateShelf(r.Context(), params); err != nil {
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if err := q.UpdateHighlight(r.Context(), params); err != nil {
+				return err
+			}
+
+			after := highlight
+			after.Page = params.Page
+			after.Content = params.Content
+			return AuditHighlight(r.Context(), q, actor.ID, "update", &highlight, &after)
+		})
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/shelves", user.Slug))
-	}, loggedinMiddleware)
+		if file != nil {
+			name, err := UploadImage(file, HIGHLIGHT_IMAGE_PATH, 1000, 1000)
+			if err != nil {
+				return InternalServerError(err)
+			}
 
-	POST("/users/{user}/shelves/{shelf}/up", func(w Response, r Request) Output {
+			err = Q.UpdateHighlightImage(r.Context(), UpdateHighlightImageParams{
+				Image: NullString(name),
+				ID:    highlight.ID,
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("highlight")
+
+	DELETE("/users/{user}/books/{isbn}/highlights/{id}", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -741,26 +3016,43 @@ func main() {
 			return NotFound
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
 			UserID: user.ID,
-			ID:     atoi64(vars["shelf"]),
+			Isbn:   vars["isbn"],
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
 		})
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "up", shelf) {
+		if !can(actor, "delete_highlight", book) {
 			return Unauthorized
 		}
 
-		if err = Q.MoveShelfUp(r.Context(), shelf.ID); err != nil {
+		err = Transaction(r.Context(), func(q *Queries) error {
+			if err := q.TrashHighlight(r.Context(), highlight.ID); err != nil {
+				return err
+			}
+
+			trashed := highlight
+			trashed.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return AuditHighlight(r.Context(), q, actor.ID, "delete", &highlight, &trashed)
+		})
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/shelves", user.Slug))
+		return Redirect(url_for("book", user.Slug, vars["isbn"]))
 	}, loggedinMiddleware)
 
-	POST("/users/{user}/shelves/{shelf}/down", func(w Response, r Request) Output {
+	GET("/users/{user}/books/{isbn}/notes/new", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -769,26 +3061,37 @@ func main() {
 			return NotFound
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
 			UserID: user.ID,
-			ID:     atoi64(vars["shelf"]),
+			Isbn:   vars["isbn"],
 		})
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "down", shelf) {
+		if !can(actor, "note", book) {
 			return Unauthorized
 		}
 
-		if err = Q.MoveShelfDown(r.Context(), shelf.ID); err != nil {
+		highlights, err := Q.Highlights(r.Context(), book.ID)
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/shelves", user.Slug))
-	}, loggedinMiddleware)
+		return Render("layout", "notes/new", Locals{
+			"current_user": actor,
+			"user":         user,
+			"book":         book,
+			"highlights":   highlights,
+			"note": NewNoteParams{
+				HighlightID: NullInt64(r.URL.Query().Get("highlight")),
+			},
+			"errors": ValidationErrors{},
+			"csrf":   CSRF(r),
+		})
+	}, loggedinMiddleware).Name("new_note")
 
-	DELETE("/users/{user}/shelves/{shelf}", func(w Response, r Request) Output {
+	POST("/users/{user}/books/{isbn}/notes", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -797,30 +3100,63 @@ func main() {
 			return NotFound
 		}
 
-		shelf, err := Q.ShelfByIdAndUser(r.Context(), ShelfByIdAndUserParams{
+		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
 			UserID: user.ID,
-			ID:     atoi64(vars["shelf"]),
+			Isbn:   vars["isbn"],
 		})
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "delete", shelf) {
+		if !can(actor, "note", book) {
 			return Unauthorized
 		}
 
-		if err = Q.RemoveShelf(r.Context(), shelf.ID); err != nil {
-			return InternalServerError(err)
+		form := NoteFromRequest(r)
+		params := NewNoteParams{
+			BookID:      book.ID,
+			HighlightID: form.HighlightID,
+			PageFrom:    form.PageFrom,
+			PageTo:      form.PageTo,
+			Content:     form.Content,
+		}
+
+		errors := params.Validate()
+		if params.HighlightID.Valid {
+			_, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+				ID:     params.HighlightID.Int64,
+				BookID: book.ID,
+			})
+			if err != nil {
+				errors.Add("highlight_id", fmt.Errorf("Highlight has to be one of the book highlights"))
+			}
+		}
+
+		if len(errors) > 0 {
+			highlights, err := Q.Highlights(r.Context(), book.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			return Render("layout", "notes/new", Locals{
+				"current_user": actor,
+				"user":         user,
+				"book":         book,
+				"highlights":   highlights,
+				"note":         params,
+				"errors":       errors,
+				"csrf":         CSRF(r),
+			})
 		}
 
-		if err = Q.DeleteShelf(r.Context(), shelf.ID); err != nil {
+		if _, err = Q.NewNote(r.Context(), params); err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/shelves", user.Slug))
-	}, loggedinMiddleware)
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("notes")
 
-	GET("/users/{user}/books/{isbn}/highlights/new", func(w Response, r Request) Output {
+	GET("/users/{user}/books/{isbn}/notes/{id}/edit", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -837,20 +3173,35 @@ func main() {
 			return NotFound
 		}
 
-		if !can(actor, "create_highlight", book) {
+		if !can(actor, "note", book) {
 			return Unauthorized
 		}
 
-		return Render("layout", "highlights/new", Locals{
+		note, err := Q.NoteByIDAndBook(r.Context(), NoteByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
+		}
+
+		highlights, err := Q.Highlights(r.Context(), book.ID)
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		return Render("layout", "notes/new", Locals{
 			"current_user": actor,
-			"book":         book,
 			"user":         user,
+			"book":         book,
+			"highlights":   highlights,
+			"note":         note,
 			"errors":       ValidationErrors{},
 			"csrf":         CSRF(r),
 		})
-	}, loggedinMiddleware)
+	}, loggedinMiddleware).Name("edit_note")
 
-	POST("/users/{user}/books/{isbn}/highlights", func(w Response, r Request) Output {
+	POST("/users/{user}/books/{isbn}/notes/{id}", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -867,60 +3218,61 @@ func main() {
 			return NotFound
 		}
 
-		if !can(actor, "create_highlight", book) {
+		if !can(actor, "note", book) {
 			return Unauthorized
 		}
 
-		r.ParseMultipartForm(MB * 10)
-
-		params := NewHighlightParams{
-			BookID:  book.ID,
-			Page:    atoi32(r.FormValue("page")),
-			Content: r.FormValue("content"),
+		note, err := Q.NoteByIDAndBook(r.Context(), NoteByIDAndBookParams{
+			ID:     atoi64(vars["id"]),
+			BookID: book.ID,
+		})
+		if err != nil {
+			return NotFound
 		}
-		errors := params.Validate()
 
-		file, _, _ := r.FormFile("image")
-		if file != nil {
-			ValidateImage(file, "image", "Image", errors, 1000, 1000)
-			file.Seek(0, os.SEEK_SET)
+		params := NoteFromRequest(r)
+		params.ID = note.ID
+
+		errors := params.Validate()
+		if params.HighlightID.Valid {
+			_, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+				ID:     params.HighlightID.Int64,
+				BookID: book.ID,
+			})
+			if err != nil {
+				errors.Add("highlight_id", fmt.Errorf("Highlight has to be one of the book highlights"))
+			}
 		}
 
 		if len(errors) > 0 {
-			return Render("layout", "highlights/new", Locals{
+			highlights, err := Q.Highlights(r.Context(), book.ID)
+			if err != nil {
+				return InternalServerError(err)
+			}
+
+			note.HighlightID = params.HighlightID
+			note.PageFrom = params.PageFrom
+			note.PageTo = params.PageTo
+			note.Content = params.Content
+			return Render("layout", "notes/new", Locals{
 				"current_user": actor,
-				"book":         book,
 				"user":         user,
-				"highlight":    params,
+				"book":         book,
+				"highlights":   highlights,
+				"note":         note,
 				"errors":       errors,
 				"csrf":         CSRF(r),
 			})
 		}
 
-		highlight, err := Q.NewHighlight(r.Context(), params)
-		if err != nil {
+		if err = Q.UpdateNote(r.Context(), params); err != nil {
 			return InternalServerError(err)
 		}
 
-		if file != nil {
-			name, err := UploadImage(file, HIGHLIGHT_IMAGE_PATH, 600, 600)
-			if err != nil {
-				return InternalServerError(err)
-			}
-
-			err = Q.UpdateHighlightImage(r.Context(), UpdateHighlightImageParams{
-				Image: NullString(name),
-				ID:    highlight.ID,
-			})
-			if err != nil {
-				return InternalServerError(err)
-			}
-		}
-
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, book.Isbn))
-	}, loggedinMiddleware)
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("note")
 
-	GET("/users/{user}/books/{isbn}/highlights/{id}/edit", func(w Response, r Request) Output {
+	DELETE("/users/{user}/books/{isbn}/notes/{id}", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -937,7 +3289,11 @@ func main() {
 			return NotFound
 		}
 
-		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
+		if !can(actor, "note", book) {
+			return Unauthorized
+		}
+
+		note, err := Q.NoteByIDAndBook(r.Context(), NoteByIDAndBookParams{
 			ID:     atoi64(vars["id"]),
 			BookID: book.ID,
 		})
@@ -945,21 +3301,14 @@ func main() {
 			return NotFound
 		}
 
-		if !can(actor, "edit_highlight", book) {
-			return Unauthorized
+		if err = Q.DeleteNote(r.Context(), note.ID); err != nil {
+			return InternalServerError(err)
 		}
 
-		return Render("layout", "highlights/new", Locals{
-			"current_user": actor,
-			"book":         book,
-			"user":         user,
-			"highlight":    highlight,
-			"errors":       ValidationErrors{},
-			"csrf":         CSRF(r),
-		})
+		return Redirect(url_for("book", user.Slug, book.Isbn))
 	}, loggedinMiddleware)
 
-	POST("/users/{user}/books/{isbn}/highlights/{id}", func(w Response, r Request) Output {
+	GET("/users/{user}/notes", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -968,75 +3317,112 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
-		})
-		if err != nil {
-			return NotFound
+		if !can(actor, "edit", user) {
+			return Unauthorized
 		}
 
-		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
-			ID:     atoi64(vars["id"]),
-			BookID: book.ID,
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		notes := []SearchNotesRow{}
+		if len(query) > 0 {
+			notes, err = Q.SearchNotes(r.Context(), SearchNotesParams{
+				UserID: user.ID,
+				Query:  EscapeLike(query),
+			})
+			if err != nil {
+				return InternalServerError(err)
+			}
+		}
+
+		return Render("layout", "notes/index", Locals{
+			"current_user": actor,
+			"user":         user,
+			"title":        "Notes",
+			"query":        query,
+			"notes":        notes,
 		})
+	}, loggedinMiddleware).Name("user_notes")
+
+	GET("/users/{user}/trash", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "edit_highlight", book) {
+		if !can(actor, "edit", user) {
 			return Unauthorized
 		}
 
-		params := UpdateHighlightParams{
-			Page:    atoi32(r.FormValue("page")),
-			Content: r.FormValue("content"),
-			ID:      highlight.ID,
+		data, err := TrashLocals(r.Context(), user.ID)
+		if err != nil {
+			return InternalServerError(err)
 		}
-		errors := params.Validate()
 
-		file, _, _ := r.FormFile("image")
-		if file != nil {
-			ValidateImage(file, "image", "Image", errors, 1000, 1000)
-			file.Seek(0, os.SEEK_SET)
+		data["current_user"] = actor
+		data["user"] = user
+		data["csrf"] = CSRF(r)
+
+		return Render("layout", "trash/index", data)
+	}, loggedinMiddleware).Name("trash")
+
+	POST("/users/{user}/trash/books/{id}/restore", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
+		if err != nil {
+			return NotFound
 		}
 
-		if len(errors) > 0 {
-			highlight.Content = params.Content
-			highlight.Page = params.Page
-			return Render("layout", "highlights/new", Locals{
-				"current_user": actor,
-				"user":         user,
-				"book":         book,
-				"highlight":    highlight,
-				"errors":       errors,
-				"csrf":         CSRF(r),
-			})
+		if !can(actor, "edit", user) {
+			return Unauthorized
 		}
 
-		if err = Q.UpdateHighlight(r.Context(), params); err != nil {
-			return InternalServerError(err)
+		book, err := Q.TrashedBookByIDAndUser(r.Context(), TrashedBookByIDAndUserParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err != nil {
+			return NotFound
 		}
 
-		if file != nil {
-			name, err := UploadImage(file, HIGHLIGHT_IMAGE_PATH, 1000, 1000)
+		// the same ISBN may have been added again after it was deleted
+		_, err = Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
+			UserID: user.ID,
+			Isbn:   book.Isbn,
+		})
+		if err == nil {
+			data, err := TrashLocals(r.Context(), user.ID)
 			if err != nil {
 				return InternalServerError(err)
 			}
 
-			err = Q.UpdateHighlightImage(r.Context(), UpdateHighlightImageParams{
-				Image: NullString(name),
-				ID:    highlight.ID,
+			data["current_user"] = actor
+			data["user"] = user
+			data["csrf"] = CSRF(r)
+			data["error"] = fmt.Sprintf("%s can't be restored, a book with ISBN %s is already in your library", book.Title, book.Isbn)
+
+			return Render("layout", "trash/index", data)
+		}
+		if err != sql.ErrNoRows {
+			return InternalServerError(err)
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			return AuditBook(r.Context(), q, actor.ID, book.ID, "restore", func() error {
+				return q.RestoreBook(r.Context(), book.ID)
 			})
-			if err != nil {
-				return InternalServerError(err)
-			}
+		})
+		if err != nil {
+			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, book.Isbn))
-	}, loggedinMiddleware)
+		return Redirect(url_for("book", user.Slug, book.Isbn))
+	}, loggedinMiddleware).Name("restore_book")
 
-	DELETE("/users/{user}/books/{isbn}/highlights/{id}", func(w Response, r Request) Output {
+	POST("/users/{user}/trash/highlights/{id}/restore", func(w Response, r Request) Output {
 		actor := current_user(r)
 		vars := VARS(r)
 
@@ -1045,37 +3431,68 @@ func main() {
 			return NotFound
 		}
 
-		book, err := Q.BookByIsbnAndUser(r.Context(), BookByIsbnAndUserParams{
-			UserID: user.ID,
-			Isbn:   vars["isbn"],
+		if !can(actor, "edit", user) {
+			return Unauthorized
+		}
+
+		err = Transaction(r.Context(), func(q *Queries) error {
+			highlight, err := q.RestoreHighlight(r.Context(), RestoreHighlightParams{
+				ID:     atoi64(vars["id"]),
+				UserID: user.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			trashed := highlight
+			trashed.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return AuditHighlight(r.Context(), q, actor.ID, "restore", &trashed, &highlight)
 		})
-		if err != nil {
+		if err == sql.ErrNoRows {
 			return NotFound
 		}
+		if err != nil {
+			return InternalServerError(err)
+		}
 
-		highlight, err := Q.HighlightByIDAndBook(r.Context(), HighlightByIDAndBookParams{
-			ID:     atoi64(vars["id"]),
-			BookID: book.ID,
-		})
+		return Redirect(url_for("trash", user.Slug))
+	}, loggedinMiddleware).Name("restore_highlight")
+
+	POST("/users/{user}/trash/shelves/{id}/restore", func(w Response, r Request) Output {
+		actor := current_user(r)
+		vars := VARS(r)
+
+		user, err := Q.UserBySlug(r.Context(), vars["user"])
 		if err != nil {
 			return NotFound
 		}
 
-		if !can(actor, "delete_highlight", book) {
+		if !can(actor, "edit", user) {
 			return Unauthorized
 		}
 
-		if highlight.Image.Valid && len(highlight.Image.String) > 0 {
-			os.Remove(path.Join(HIGHLIGHT_IMAGE_PATH, highlight.Image.String))
+		_, err = Q.RestoreShelf(r.Context(), RestoreShelfParams{
+			ID:     atoi64(vars["id"]),
+			UserID: user.ID,
+		})
+		if err == sql.ErrNoRows {
+			return NotFound
 		}
-
-		if err = Q.DeleteHighlight(r.Context(), highlight.ID); err != nil {
+		if err != nil {
 			return InternalServerError(err)
 		}
 
-		return Redirect(fmt.Sprintf("/users/%s/books/%s", user.Slug, vars["isbn"]))
-	}, loggedinMiddleware)
+		return Redirect(url_for("shelves", user.Slug))
+	}, loggedinMiddleware).Name("restore_shelf")
+
+	READY_CHECK("book covers directory", WritableDir(BOOK_COVER_PATH))
+	READY_CHECK("highlight images directory", WritableDir(HIGHLIGHT_IMAGE_PATH))
 
 	Helpers()
+	go ResumeMetadataJobs()
+	go ResumeBulkAdds()
+	go MirrorCovers()
+	go PurgeTrash()
 	Start()
+	return nil
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// Markdown renders a small subset of markdown to HTML: headings, paragraphs,
+// lists, block quotes, fenced code, emphasis, inline code and links. The input
+// is escaped before any tag is added so the output is safe to render as is.
+func Markdown(src string) template.HTML {
+	var out strings.Builder
+	var para, quote []string
+	list := ""
+	code := false
+
+	flushPara := func() {
+		if len(para) > 0 {
+			fmt.Fprintf(&out, "<p>%s</p>\n", markdownInline(strings.Join(para, "\n")))
+			para = nil
+		}
+	}
+	flushQuote := func() {
+		if len(quote) > 0 {
+			fmt.Fprintf(&out, "<blockquote><p>%s</p></blockquote>\n", markdownInline(strings.Join(quote, "\n")))
+			quote = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			fmt.Fprintf(&out, "</%s>\n", list)
+			list = ""
+		}
+	}
+	flush := func() {
+		flushPara()
+		flushQuote()
+		closeList()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if code {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				out.WriteString("</code></pre>\n")
+				code = false
+			} else {
+				out.WriteString(html.EscapeString(line) + "\n")
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			out.WriteString("<pre><code>")
+			code = true
+
+		case markdownHeading.MatchString(trimmed):
+			flush()
+			m := markdownHeading.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&out, "<h%d>%s</h%d>\n", len(m[1]), markdownInline(m[2]), len(m[1]))
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			closeList()
+			quote = append(quote, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+
+		case markdownBullet.MatchString(trimmed), markdownNumbered.MatchString(trimmed):
+			flushPara()
+			flushQuote()
+			tag, re := "ul", markdownBullet
+			if markdownNumbered.MatchString(trimmed) {
+				tag, re = "ol", markdownNumbered
+			}
+			if list != tag {
+				closeList()
+				fmt.Fprintf(&out, "<%s>\n", tag)
+				list = tag
+			}
+			fmt.Fprintf(&out, "<li>%s</li>\n", markdownInline(re.ReplaceAllString(trimmed, "")))
+
+		default:
+			flushQuote()
+			closeList()
+			para = append(para, trimmed)
+		}
+	}
+
+	if code {
+		out.WriteString("</code></pre>\n")
+	}
+	flush()
+
+	return template.HTML(out.String())
+}
+
+var markdownTag = regexp.MustCompile(`<[^>]*>`)
+
+// MarkdownText renders the markdown then keeps its text without tags and
+// extra spaces, cut at a word boundary to max characters for meta tags
+func MarkdownText(src string, max int) string {
+	text := markdownTag.ReplaceAllString(string(Markdown(src)), " ")
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	cut := string(runes[:max])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+
+	return cut + "…"
+}
+
+var (
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	markdownBullet   = regexp.MustCompile(`^[-*+]\s+`)
+	markdownNumbered = regexp.MustCompile(`^\d+[.)]\s+`)
+	markdownCode     = regexp.MustCompile("`([^`]+)`")
+	markdownStrong   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownEm       = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// markdownInline escapes a line of text then converts inline code, emphasis
+// and links. code spans are kept away from the other replacements.
+func markdownInline(s string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range markdownCode.FindAllStringSubmatchIndex(s, -1) {
+		out.WriteString(markdownSpan(s[last:m[0]]))
+		out.WriteString("<code>" + html.EscapeString(s[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	out.WriteString(markdownSpan(s[last:]))
+
+	return strings.ReplaceAll(out.String(), "\n", "<br/>")
+}
+
+// markdownSpan converts links and emphasis, link urls are only escaped so
+// emphasis markers in them are kept as is
+func markdownSpan(s string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range markdownLink.FindAllStringSubmatchIndex(s, -1) {
+		out.WriteString(markdownEmphasis(s[last:m[0]]))
+		text, url := markdownEmphasis(s[m[2]:m[3]]), s[m[4]:m[5]]
+		last = m[1]
+
+		lower := strings.ToLower(url)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "mailto:") {
+			out.WriteString(text)
+			continue
+		}
+
+		fmt.Fprintf(&out, `<a href="%s" rel="nofollow noopener">%s</a>`, html.EscapeString(url), text)
+	}
+	out.WriteString(markdownEmphasis(s[last:]))
+
+	return out.String()
+}
+
+func markdownEmphasis(s string) string {
+	s = html.EscapeString(s)
+	s = markdownStrong.ReplaceAllString(s, "<strong>$1$2</strong>")
+	return markdownEm.ReplaceAllString(s, "<em>$1$2</em>")
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	GOOGLE_BOOKS_URL   = "https://www.googleapis.com"
+	OPEN_LIBRARY_URL   = "https://openlibrary.org"
+	METADATA_CACHE_TTL = 30 * 24 * time.Hour
+	METADATA_TIMEOUT   = 10 * time.Second
+	METADATA_LIMIT     = 10
+	METADATA_RATE      = time.Second // a provider is asked at most once every METADATA_RATE
+	METADATA_MAX_SIZE  = 5 * MB      // larger responses fail to decode
+)
+
+// BookMetadata is a book found by a metadata provider, it holds what's needed
+// to fill the new book form
+type BookMetadata struct {
+	Provider      string
+	Isbn          string
+	Title         string
+	Subtitle      string
+	Author        string
+	Description   string
+	Publisher     string
+	PageCount     int32
+	GoogleBooksID string
+	Cover         string
+
+	SeriesName     string
+	SeriesPosition int32
+	GoogleSeriesID string
+}
+
+func (m BookMetadata) NewBookParams() NewBookParams {
+	return NewBookParams{
+		Title:         m.Title,
+		Isbn:          m.Isbn,
+		Author:        m.Author,
+		Subtitle:      m.Subtitle,
+		Description:   m.Description,
+		Publisher:     m.Publisher,
+		PageCount:     m.PageCount,
+		GoogleBooksID: NullString(m.GoogleBooksID),
+	}
+}
+
+// SeriesForm fills the book form series fields
+func (m BookMetadata) SeriesForm() SeriesForm {
+	s := SeriesForm{Name: m.SeriesName, GoogleSeriesID: m.GoogleSeriesID}
+	if m.SeriesPosition > 0 {
+		s.Position = sql.NullInt32{Int32: m.SeriesPosition, Valid: true}
+	}
+
+	return s
+}
+
+func (m BookMetadata) NewWishParams() NewWishParams {
+	return NewWishParams{
+		Title:          m.Title,
+		Isbn:           m.Isbn,
+		Author:         m.Author,
+		Subtitle:       m.Subtitle,
+		Description:    m.Description,
+		Publisher:      m.Publisher,
+		PageCount:      m.PageCount,
+		GoogleBooksID:  NullString(m.GoogleBooksID),
+		SeriesName:     m.SeriesName,
+		SeriesPosition: m.SeriesForm().Position,
+	}
+}
+
+// MetadataProvider searches an external books database by ISBN or by free
+// text like a title or an author name
+type MetadataProvider interface {
+	Name() string
+	Search(ctx context.Context, query string) ([]BookMetadata, error)
+}
+
+// METADATA_PROVIDERS are asked in order, results are listed in the same order
+var METADATA_PROVIDERS = MetadataProviders(GOOGLE_BOOKS_URL, OPEN_LIBRARY_URL)
+
+// MetadataProviders returns the rate limited providers requesting the APIs at
+// the base URLs
+func MetadataProviders(googleBooksURL, openLibraryURL string) []MetadataProvider {
+	// google series names are requested separately and take turns too
+	google := NewRateLimiter(METADATA_RATE)
+
+	return []MetadataProvider{
+		RateLimit(GoogleBooks{BaseURL: googleBooksURL, Limiter: google}, google),
+		RateLimit(OpenLibrary{BaseURL: openLibraryURL}, NewRateLimiter(METADATA_RATE)),
+	}
+}
+
+// RateLimiter spaces requests to an API at least every duration apart
+type RateLimiter struct {
+	every time.Duration
+	mu    sync.Mutex
+	next  time.Time
+}
+
+func NewRateLimiter(every time.Duration) *RateLimiter {
+	return &RateLimiter{every: every}
+}
+
+// Wait returns when it's the caller turn or with the error of ctx when it's
+// done first
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait, ok := l.take()
+		if ok {
+			return nil
+		}
+
+		// the turn is taken when the caller wakes up, a cancelled caller
+		// never holds one
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take takes the turn if it's due or returns how long until it is
+func (l *RateLimiter) take() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.next) {
+		return l.next.Sub(now), false
+	}
+
+	l.next = now.Add(l.every)
+	return 0, true
+}
+
+type rateLimited struct {
+	MetadataProvider
+	limiter *RateLimiter
+}
+
+// RateLimit makes the provider searches wait for their turn of the limiter
+func RateLimit(p MetadataProvider, limiter *RateLimiter) MetadataProvider {
+	return &rateLimited{MetadataProvider: p, limiter: limiter}
+}
+
+func (r *rateLimited) Search(ctx context.Context, query string) ([]BookMetadata, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.MetadataProvider.Search(ctx, query)
+}
+
+var notISBNChars = regexp.MustCompile(`[\s-]`)
+
+// MetadataQuery normalizes the query so the same search hits the cache, ISBNs
+// are stripped from dashes and spaces
+func MetadataQuery(query string) (q string, isbn bool) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if stripped := notISBNChars.ReplaceAllString(query, ""); isISBN(stripped) {
+		return stripped, true
+	}
+
+	return query, false
+}
+
+func isISBN(s string) bool {
+	if len(s) != 10 && len(s) != 13 {
+		return false
+	}
+
+	for i, c := range s {
+		if (c < '0' || c > '9') && !(i == 9 && len(s) == 10 && c == 'x') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LookupMetadata searches all providers, results are cached per provider and
+// query. a failing provider is logged and skipped so others can still be used
+func LookupMetadata(ctx context.Context, q *Queries, query string) []BookMetadata {
+	query, _ = MetadataQuery(query)
+	results := []BookMetadata{}
+	if len(query) == 0 {
+		return results
+	}
+
+	for _, p := range METADATA_PROVIDERS {
+		found, err := cachedSearch(ctx, q, p, query)
+		if err != nil {
+			log.Printf("Metadata provider %s failed for %s: %s", p.Name(), query, err)
+			continue
+		}
+
+		results = append(results, found...)
+	}
+
+	return results
+}
+
+func cachedSearch(ctx context.Context, q *Queries, p MetadataProvider, query string) ([]BookMetadata, error) {
+	found := []BookMetadata{}
+
+	cached, err := q.MetadataLookup(ctx, MetadataLookupParams{
+		Provider:  p.Name(),
+		Query:     query,
+		CreatedAt: time.Now().Add(-METADATA_CACHE_TTL),
+	})
+	if err == nil {
+		err = json.Unmarshal(cached.Results, &found)
+		return found, err
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, METADATA_TIMEOUT)
+	defer cancel()
+
+	if found, err = p.Search(ctx, query); err != nil {
+		return nil, err
+	}
+
+	results, err := json.Marshal(found)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.SaveMetadataLookup(ctx, SaveMetadataLookupParams{
+		Provider: p.Name(),
+		Query:    query,
+		Results:  results,
+	})
+
+	return found, err
+}
+
+// getJSON requests the url and decodes the JSON response to v
+func getJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", u, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, METADATA_MAX_SIZE)).Decode(v)
+}
+
+// GoogleBooks searches the Google Books volumes API
+type GoogleBooks struct {
+	BaseURL string
+	Client  *http.Client
+	Limiter *RateLimiter // series requests wait for its turns when it's set
+}
+
+func (GoogleBooks) Name() string { return "google_books" }
+
+func (g GoogleBooks) Search(ctx context.Context, query string) ([]BookMetadata, error) {
+	if q, isbn := MetadataQuery(query); isbn {
+		query = "isbn:" + q
+	}
+
+	var data struct {
+		Items []struct {
+			ID         string
+			VolumeInfo struct {
+				Title               string
+				Subtitle            string
+				Authors             []string
+				Publisher           string
+				Description         string
+				PageCount           int32
+				IndustryIdentifiers []struct {
+					Type       string
+					Identifier string
+				}
+				SeriesInfo *struct {
+					BookDisplayNumber string
+					VolumeSeries      []struct {
+						SeriesID    string
+						OrderNumber int32
+					}
+				}
+			}
+		}
+	}
+
+	u := fmt.Sprintf("%s/books/v1/volumes?maxResults=%d&q=%s", g.BaseURL, METADATA_LIMIT, url.QueryEscape(query))
+	if err := getJSON(ctx, g.Client, u, &data); err != nil {
+		return nil, err
+	}
+
+	books := []BookMetadata{}
+	for _, item := range data.Items {
+		info := item.VolumeInfo
+		book := BookMetadata{
+			Provider:      g.Name(),
+			Title:         info.Title,
+			Subtitle:      info.Subtitle,
+			Author:        strings.Join(info.Authors, ", "),
+			Description:   info.Description,
+			Publisher:     info.Publisher,
+			PageCount:     info.PageCount,
+			GoogleBooksID: item.ID,
+			Cover:         book_cover("", item.ID),
+		}
+
+		for _, id := range info.IndustryIdentifiers {
+			if id.Type == "ISBN_13" {
+				book.Isbn = id.Identifier
+			}
+		}
+
+		if series := info.SeriesInfo; series != nil {
+			book.SeriesPosition = atoi32(series.BookDisplayNumber)
+			if len(series.VolumeSeries) > 0 {
+				book.GoogleSeriesID = series.VolumeSeries[0].SeriesID
+				if n := series.VolumeSeries[0].OrderNumber; n > 0 {
+					book.SeriesPosition = n
+				}
+			}
+		}
+
+		books = append(books, book)
+	}
+
+	// volumes only have the series ID, names are requested once per series
+	// and a failure leaves the name empty
+	names := map[string]string{}
+	for i, book := range books {
+		id := book.GoogleSeriesID
+		if len(id) == 0 {
+			continue
+		}
+
+		if _, ok := names[id]; !ok {
+			names[id] = g.seriesName(ctx, id)
+		}
+		books[i].SeriesName = names[id]
+	}
+
+	return books, nil
+}
+
+func (g GoogleBooks) seriesName(ctx context.Context, id string) string {
+	var data struct {
+		Series []struct {
+			Title string
+		}
+	}
+
+	if g.Limiter != nil && g.Limiter.Wait(ctx) != nil {
+		return ""
+	}
+
+	u := fmt.Sprintf("%s/books/v1/series/get?series_id=%s", g.BaseURL, url.QueryEscape(id))
+	if err := getJSON(ctx, g.Client, u, &data); err != nil || len(data.Series) == 0 {
+		return ""
+	}
+
+	return data.Series[0].Title
+}
+
+// OpenLibrary searches the Open Library search API
+type OpenLibrary struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func (OpenLibrary) Name() string { return "open_library" }
+
+func (o OpenLibrary) Search(ctx context.Context, query string) ([]BookMetadata, error) {
+	param := "q"
+	q, isbn := MetadataQuery(query)
+	if isbn {
+		param, query = "isbn", q
+	}
+
+	var data struct {
+		Docs []struct {
+			Title               string   `json:"title"`
+			Subtitle            string   `json:"subtitle"`
+			AuthorName          []string `json:"author_name"`
+			Publisher           []string `json:"publisher"`
+			Isbn                []string `json:"isbn"`
+			NumberOfPagesMedian int32    `json:"number_of_pages_median"`
+		} `json:"docs"`
+	}
+
+	u := fmt.Sprintf("%s/search.json?limit=%d&%s=%s", o.BaseURL, METADATA_LIMIT, param, url.QueryEscape(query))
+	if err := getJSON(ctx, o.Client, u, &data); err != nil {
+		return nil, err
+	}
+
+	books := []BookMetadata{}
+	for _, doc := range data.Docs {
+		book := BookMetadata{
+			Provider:  o.Name(),
+			Title:     doc.Title,
+			Subtitle:  doc.Subtitle,
+			Author:    strings.Join(doc.AuthorName, ", "),
+			PageCount: doc.NumberOfPagesMedian,
+		}
+
+		if len(doc.Publisher) > 0 {
+			book.Publisher = doc.Publisher[0]
+		}
+
+		// a work has many editions, prefer the searched ISBN
+		for _, i := range doc.Isbn {
+			if len(i) == 13 && (book.Isbn == "" || i == q) {
+				book.Isbn = i
+			}
+		}
+
+		books = append(books, book)
+	}
+
+	return books, nil
+}
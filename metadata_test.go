@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func fakeAPI(t *testing.T, routes map[string]string) (*httptest.Server, map[string]int) {
+	t.Helper()
+	requests := map[string]int{}
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.RequestURI()]++
+		mu.Unlock()
+
+		body, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func TestGoogleBooksSearch(t *testing.T) {
+	srv, requests := fakeAPI(t, map[string]string{
+		"/books/v1/volumes?maxResults=10&q=isbn%3A9780441013593": `{"items": [
+			{
+				"id": "B1yaDwAAQBAJ",
+				"volumeInfo": {
+					"title": "Dune",
+					"subtitle": "Deluxe Edition",
+					"authors": ["Frank Herbert", "Brian Herbert"],
+					"publisher": "Ace",
+					"description": "Set on the desert planet Arrakis",
+					"pageCount": 896,
+					"industryIdentifiers": [
+						{"type": "ISBN_10", "identifier": "0441013597"},
+						{"type": "ISBN_13", "identifier": "9780441013593"}
+					],
+					"seriesInfo": {
+						"bookDisplayNumber": "1",
+						"volumeSeries": [{"seriesId": "4RDyGQAAABAJ", "orderNumber": 1}]
+					}
+				}
+			},
+			{
+				"id": "c2",
+				"volumeInfo": {
+					"title": "Dune Messiah",
+					"seriesInfo": {
+						"bookDisplayNumber": "2",
+						"volumeSeries": [{"seriesId": "4RDyGQAAABAJ"}]
+					}
+				}
+			},
+			{"id": "c3", "volumeInfo": {"title": "Standalone"}}
+		]}`,
+		"/books/v1/series/get?series_id=4RDyGQAAABAJ": `{"series": [{"title": "Dune Chronicles"}]}`,
+	})
+
+	books, err := GoogleBooks{BaseURL: srv.URL}.Search(context.Background(), "978-0-441-01359-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []BookMetadata{
+		{
+			Provider:       "google_books",
+			Isbn:           "9780441013593",
+			Title:          "Dune",
+			Subtitle:       "Deluxe Edition",
+			Author:         "Frank Herbert, Brian Herbert",
+			Description:    "Set on the desert planet Arrakis",
+			Publisher:      "Ace",
+			PageCount:      896,
+			GoogleBooksID:  "B1yaDwAAQBAJ",
+			Cover:          "/books/google/B1yaDwAAQBAJ",
+			SeriesName:     "Dune Chronicles",
+			SeriesPosition: 1,
+			GoogleSeriesID: "4RDyGQAAABAJ",
+		},
+		{
+			Provider:       "google_books",
+			Title:          "Dune Messiah",
+			GoogleBooksID:  "c2",
+			Cover:          "/books/google/c2",
+			SeriesName:     "Dune Chronicles",
+			SeriesPosition: 2,
+			GoogleSeriesID: "4RDyGQAAABAJ",
+		},
+		{
+			Provider:      "google_books",
+			Title:         "Standalone",
+			GoogleBooksID: "c3",
+			Cover:         "/books/google/c3",
+		},
+	}
+	if !reflect.DeepEqual(books, expected) {
+		t.Errorf("expected %+v, got %+v", expected, books)
+	}
+
+	if n := requests["/books/v1/series/get?series_id=4RDyGQAAABAJ"]; n != 1 {
+		t.Errorf("expected the series to be requested once, requested %d times", n)
+	}
+}
+
+func TestGoogleBooksSearchSeriesFailure(t *testing.T) {
+	srv, _ := fakeAPI(t, map[string]string{
+		"/books/v1/volumes?maxResults=10&q=dune": `{"items": [{"id": "c1", "volumeInfo": {
+			"title": "Dune",
+			"seriesInfo": {"volumeSeries": [{"seriesId": "missing", "orderNumber": 3}]}
+		}}]}`,
+	})
+
+	books, err := GoogleBooks{BaseURL: srv.URL}.Search(context.Background(), "dune")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(books) != 1 {
+		t.Fatalf("expected one book, got %+v", books)
+	}
+
+	form := books[0].SeriesForm()
+	if form.Name != "" || form.GoogleSeriesID != "missing" || form.Position.Int32 != 3 || !form.Position.Valid {
+		t.Errorf("expected the series without a name in position 3, got %+v", form)
+	}
+}
+
+func TestGoogleBooksSearchError(t *testing.T) {
+	srv, _ := fakeAPI(t, map[string]string{})
+
+	if _, err := (GoogleBooks{BaseURL: srv.URL}).Search(context.Background(), "dune"); err == nil {
+		t.Error("expected an error when the API responds with 404")
+	}
+}
+
+func TestOpenLibrarySearch(t *testing.T) {
+	srv, _ := fakeAPI(t, map[string]string{
+		"/search.json?limit=10&isbn=9780441013593": `{"docs": [{
+			"title": "Dune",
+			"author_name": ["Frank Herbert"],
+			"publisher": ["Ace", "Chilton Books"],
+			"isbn": ["0441013597", "9780340960196", "9780441013593", "9780593099322"],
+			"number_of_pages_median": 617
+		}]}`,
+		"/search.json?limit=10&q=frank+herbert": `{"docs": [
+			{"title": "Dune", "isbn": ["0441013597", "9780340960196", "9780441013593"]},
+			{"title": "No Editions"}
+		]}`,
+	})
+
+	tests := []struct {
+		query    string
+		expected []BookMetadata
+	}{
+		{
+			query: "978-0441013593",
+			expected: []BookMetadata{{
+				Provider:  "open_library",
+				Isbn:      "9780441013593",
+				Title:     "Dune",
+				Author:    "Frank Herbert",
+				Publisher: "Ace",
+				PageCount: 617,
+			}},
+		},
+		{
+			query: "frank herbert",
+			expected: []BookMetadata{
+				{
+					Provider: "open_library",
+					Isbn:     "9780340960196",
+					Title:    "Dune",
+				},
+				{Provider: "open_library", Title: "No Editions"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			books, err := OpenLibrary{BaseURL: srv.URL}.Search(context.Background(), tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(books, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, books)
+			}
+		})
+	}
+}
+
+func TestMetadataProviders(t *testing.T) {
+	google, _ := fakeAPI(t, map[string]string{
+		"/books/v1/volumes?maxResults=10&q=dune": `{"items": [{"id": "c1", "volumeInfo": {"title": "Dune"}}]}`,
+	})
+	openLibrary, _ := fakeAPI(t, map[string]string{
+		"/search.json?limit=10&q=dune": `{"docs": [{"title": "Dune"}]}`,
+	})
+
+	providers := MetadataProviders(google.URL, openLibrary.URL)
+	for _, p := range providers {
+		books, err := p.Search(context.Background(), "dune")
+		if err != nil {
+			t.Fatalf("%s: %s", p.Name(), err)
+		}
+
+		if len(books) != 1 || books[0].Title != "Dune" || books[0].Provider != p.Name() {
+			t.Errorf("%s: expected Dune, got %+v", p.Name(), books)
+		}
+	}
+}
+
+type countingProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (*countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Search(ctx context.Context, query string) ([]BookMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return nil, nil
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	every := 100 * time.Millisecond
+	p := &countingProvider{}
+	limited := RateLimit(p, NewRateLimiter(every))
+
+	if _, err := limited.Search(context.Background(), "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// callers giving up before their turn shouldn't delay the ones after them
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), every/10)
+		_, err := limited.Search(ctx, "cancelled")
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected the search to time out, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*every)
+	defer cancel()
+	if _, err := limited.Search(ctx, "second"); err != nil {
+		t.Fatalf("expected the search to get the next turn, got %v", err)
+	}
+
+	if p.calls != 2 {
+		t.Errorf("expected 2 searches, got %d", p.calls)
+	}
+}
+
+func TestGoogleBooksSeriesRateLimit(t *testing.T) {
+	srv, _ := fakeAPI(t, map[string]string{
+		"/books/v1/volumes?maxResults=10&q=herbert": `{"items": [
+			{"id": "c1", "volumeInfo": {"title": "Dune", "seriesInfo": {"volumeSeries": [{"seriesId": "s1"}]}}},
+			{"id": "c2", "volumeInfo": {"title": "Destination: Void", "seriesInfo": {"volumeSeries": [{"seriesId": "s2"}]}}}
+		]}`,
+		"/books/v1/series/get?series_id=s1": `{"series": [{"title": "Dune Chronicles"}]}`,
+		"/books/v1/series/get?series_id=s2": `{"series": [{"title": "Pandora Sequence"}]}`,
+	})
+
+	// the search and each series request take a turn
+	every := 50 * time.Millisecond
+	limiter := NewRateLimiter(every)
+	p := RateLimit(GoogleBooks{BaseURL: srv.URL, Limiter: limiter}, limiter)
+
+	start := time.Now()
+	books, err := p.Search(context.Background(), "herbert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 2*every {
+		t.Errorf("expected 3 turns to take at least %s, took %s", 2*every, elapsed)
+	}
+
+	if len(books) != 2 || books[0].SeriesName != "Dune Chronicles" || books[1].SeriesName != "Pandora Sequence" {
+		t.Errorf("expected both series names, got %+v", books)
+	}
+}
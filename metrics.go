@@ -0,0 +1,298 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics are exposed on /metrics in the Prometheus text format. admins can
+// open it logged in, otherwise it's disabled unless METRICS_TOKEN is set and
+// requests have to send it as a bearer token.
+
+var DEFAULT_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	httpRequests = NewCounter(
+		"http_requests_total",
+		"HTTP requests by method, route name and status. route is empty for unmatched paths",
+		"method", "route", "status",
+	)
+	httpRequestDuration = NewHistogram(
+		"http_request_duration_seconds",
+		"HTTP request latency by method, route name and status",
+		DEFAULT_BUCKETS,
+		"method", "route", "status",
+	)
+	dbQueries = NewCounter(
+		"db_queries_total",
+		"Database queries by kind: exec, query or row",
+		"kind",
+	)
+	dbQueryDuration = NewHistogram(
+		"db_query_duration_seconds",
+		"Database query duration by kind",
+		DEFAULT_BUCKETS,
+		"kind",
+	)
+	imageUploadSize = NewHistogram(
+		"image_upload_bytes",
+		"Size of uploaded images before resizing",
+		[]float64{float64(10 * KB), float64(50 * KB), float64(100 * KB), float64(250 * KB), float64(500 * KB), float64(MB), float64(2 * MB), float64(5 * MB), float64(10 * MB)},
+	)
+	imageProcessingDuration = NewHistogram(
+		"image_processing_duration_seconds",
+		"Time to resize and save uploaded images",
+		DEFAULT_BUCKETS,
+	)
+)
+
+// sql.DB pool stats are read on every scrape
+var dbPoolMetrics = []*GaugeFunc{
+	NewGaugeFunc("db_max_open_connections", "Maximum open connections to the database", func() float64 {
+		return float64(DB.Stats().MaxOpenConnections)
+	}),
+	NewGaugeFunc("db_open_connections", "Open connections to the database, in use and idle", func() float64 {
+		return float64(DB.Stats().OpenConnections)
+	}),
+	NewGaugeFunc("db_in_use_connections", "Database connections in use", func() float64 {
+		return float64(DB.Stats().InUse)
+	}),
+	NewGaugeFunc("db_idle_connections", "Idle database connections", func() float64 {
+		return float64(DB.Stats().Idle)
+	}),
+	NewGaugeFunc("db_wait_count", "Times a query waited for a database connection", func() float64 {
+		return float64(DB.Stats().WaitCount)
+	}),
+	NewGaugeFunc("db_wait_duration_seconds", "Time spent waiting for database connections", func() float64 {
+		return DB.Stats().WaitDuration.Seconds()
+	}),
+}
+
+// metric is a metric family that writes itself in the Prometheus text format
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   []metric
+)
+
+func registerMetric(m metric) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics = append(metrics, m)
+}
+
+func metricName(name string) string {
+	return APP_NAME + "_" + name
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats label names and values as {name="value",...}
+func labels(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+len(extra)/2)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// series keeps label values by their joined key so they're written sorted
+type series struct {
+	keys   []string
+	values map[string][]string
+}
+
+func (s *series) add(values []string) string {
+	key := strings.Join(values, "\xff")
+	if s.values == nil {
+		s.values = map[string][]string{}
+	}
+
+	if _, ok := s.values[key]; !ok {
+		s.values[key] = append([]string{}, values...)
+		s.keys = append(s.keys, key)
+		sort.Strings(s.keys)
+	}
+
+	return key
+}
+
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series series
+	counts map[string]float64
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: metricName(name), help: help, labels: labels, counts: map[string]float64{}}
+	registerMetric(c)
+	return c
+}
+
+// Inc adds one to the counter of the label values
+func (c *Counter) Inc(values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.series.add(values)]++
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range c.series.keys {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labels(c.labels, c.series.values[key]), formatFloat(c.counts[key]))
+	}
+}
+
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // upper bounds ending with +Inf
+
+	mu     sync.Mutex
+	series series
+	counts map[string][]uint64 // observations per bucket, not cumulative
+	sums   map[string]float64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    metricName(name),
+		help:    help,
+		labels:  labels,
+		buckets: append(append([]float64{}, buckets...), math.Inf(1)),
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+	}
+	registerMetric(h)
+	return h
+}
+
+// Observe adds the value to the histogram of the label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.series.add(values)
+	if h.counts[key] == nil {
+		h.counts[key] = make([]uint64, len(h.buckets))
+	}
+
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.counts[key][i]++
+	h.sums[key] += v
+}
+
+// Since observes the seconds passed since start
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range h.series.keys {
+		values := h.series.values[key]
+		var count uint64
+		for i, le := range h.buckets {
+			count += h.counts[key][i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels(h.labels, values, "le", formatFloat(le)), count)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels(h.labels, values), formatFloat(h.sums[key]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels(h.labels, values), count)
+	}
+}
+
+// GaugeFunc is a gauge read when the metrics are requested
+type GaugeFunc struct {
+	name, help string
+	value      func() float64
+}
+
+func NewGaugeFunc(name, help string, value func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: metricName(name), help: help, value: value}
+	registerMetric(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(g.value()))
+}
+
+func WriteMetrics(w io.Writer) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+func MetricsHandler(w Response, r Request) Output {
+	write := func(w Response, r Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	}
+
+	if user := current_user(r); user != nil && user.Admin {
+		return write
+	}
+
+	token := os.Getenv("METRICS_TOKEN")
+	if len(token) == 0 {
+		return NotFound
+	}
+
+	auth := r.Header.Get("Authorization")
+	if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+		return Unauthorized
+	}
+
+	return write
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
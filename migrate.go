@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	MIGRATIONS_DIR = "db/migrate"
+	// any number unique to this app, it stops two servers migrating at once
+	MIGRATIONS_LOCK_ID = 1836017516
+)
+
+//go:embed db/migrate/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// Migration is a file in db/migrate named VERSION_name.sql with the SQL to
+// apply after a "-- up" line and to revert after a "-- down" line
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations reads the embedded migrations sorted by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, MIGRATIONS_DIR)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("Migration %s: file names should be VERSION_name.sql", e.Name())
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(MIGRATIONS_DIR, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, err := ParseMigration(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("Migration %s: %w", e.Name(), err)
+		}
+
+		migration.Version = m[1]
+		migration.Name = m[2]
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// ParseMigration splits the migration SQL on the "-- up" and "-- down" lines
+func ParseMigration(content string) (Migration, error) {
+	var m Migration
+	var section *string
+	up, down := false, false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- up":
+			section, up = &m.Up, true
+			continue
+		case "-- down":
+			section, down = &m.Down, true
+			continue
+		}
+
+		if section != nil {
+			*section += line
+		}
+	}
+
+	if !up || !down {
+		return m, fmt.Errorf("it should have a -- up and a -- down line")
+	}
+
+	m.Up = strings.TrimSpace(m.Up)
+	m.Down = strings.TrimSpace(m.Down)
+	return m, nil
+}
+
+// withMigrationLock runs f on a connection holding the migrations advisory
+// lock after making sure schema_migrations exists
+func withMigrationLock(ctx context.Context, db *sql.DB, f func(*sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", MIGRATIONS_LOCK_ID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", MIGRATIONS_LOCK_ID)
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version character varying NOT NULL PRIMARY KEY)")
+	if err != nil {
+		return err
+	}
+
+	return f(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[string]bool{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions[v] = true
+	}
+
+	return versions, rows.Err()
+}
+
+// runMigration runs the SQL and records the version change in one transaction
+func runMigration(ctx context.Context, conn *sql.Conn, query, record, version string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(query) > 0 {
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, record, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Migrate applies the migrations that weren't applied yet in version order
+func Migrate(ctx context.Context, db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if applied[m.Version] {
+				continue
+			}
+
+			done := Log(INFO, "Migrate", m.Version+"_"+m.Name)
+			if err := runMigration(ctx, conn, m.Up, "INSERT INTO schema_migrations (version) VALUES ($1)", m.Version); err != nil {
+				return fmt.Errorf("Migration %s_%s: %w", m.Version, m.Name, err)
+			}
+			done()
+		}
+
+		return nil
+	})
+}
+
+// Rollback reverts the last applied migration
+func Rollback(ctx context.Context, db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		var version string
+		err := conn.QueryRowContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("There are no migrations to roll back")
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if m.Version != version {
+				continue
+			}
+
+			done := Log(INFO, "Rollback", m.Version+"_"+m.Name)
+			if err := runMigration(ctx, conn, m.Down, "DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+				return fmt.Errorf("Migration %s_%s: %w", m.Version, m.Name, err)
+			}
+
+			done()
+			return nil
+		}
+
+		return fmt.Errorf("Migration %s was applied but its file doesn't exist", version)
+	})
+}
+
+// MigrationStatus prints every migration with whether it's applied, versions
+// applied without a file are listed too
+func MigrationStatus(ctx context.Context, db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		lines := map[string]string{}
+		for v := range applied {
+			lines[v] = fmt.Sprintf("up     %s (no file)", v)
+		}
+		for _, m := range migrations {
+			status := "down"
+			if applied[m.Version] {
+				status = "up"
+			}
+			lines[m.Version] = fmt.Sprintf("%-6s %s %s", status, m.Version, m.Name)
+		}
+
+		versions := make([]string, 0, len(lines))
+		for v := range lines {
+			versions = append(versions, v)
+		}
+		sort.Strings(versions)
+
+		for _, v := range versions {
+			fmt.Println(lines[v])
+		}
+
+		return nil
+	})
+}
+
+// CreateMigration writes an empty migration file to db/migrate, the server
+// has to be built again to embed it
+func CreateMigration(name string) (string, error) {
+	file := fmt.Sprintf("%s_%s.sql", time.Now().UTC().Format("20060102150405"), name)
+	if !migrationName.MatchString(file) {
+		return "", fmt.Errorf("Migration name %s should have only lower case letters, digits and underscores", name)
+	}
+
+	p := path.Join(MIGRATIONS_DIR, file)
+	if err := os.WriteFile(p, []byte("-- up\n\n-- down\n"), 0644); err != nil {
+		return "", err
+	}
+
+	return p, nil
+}
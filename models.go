@@ -4,6 +4,7 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -14,6 +15,27 @@ type ArInternalMetadatum struct {
 	UpdatedAt time.Time
 }
 
+type AuditLog struct {
+	ID        int64
+	ActorID   sql.NullInt64
+	BookID    int64
+	Entity    string
+	EntityID  int64
+	Action    string
+	Changes   json.RawMessage
+	CreatedAt time.Time
+	UserID    int64
+	BookTitle string
+}
+
+type Author struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type Book struct {
 	ID            int64
 	Title         string
@@ -22,7 +44,6 @@ type Book struct {
 	Isbn          string
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
-	ShelfID       sql.NullInt64
 	UserID        int64
 	GoogleBooksID sql.NullString
 	Subtitle      string
@@ -30,6 +51,52 @@ type Book struct {
 	PageCount     int32
 	Publisher     string
 	PageRead      int32
+	WorkID        int64
+	Rating        sql.NullInt16
+	Review        string
+	DeletedAt     sql.NullTime
+}
+
+type BookAuthor struct {
+	BookID   int64
+	AuthorID int64
+	Role     string
+	Position int32
+}
+
+type BookTag struct {
+	BookID int64
+	TagID  int64
+}
+
+type BulkAdd struct {
+	ID        int64
+	UserID    int64
+	ShelfID   sql.NullInt64
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BulkAddBook struct {
+	ID        int64
+	BulkAddID int64
+	Isbn      string
+	Status    string
+	Title     string
+	Error     string
+}
+
+type Copy struct {
+	ID         int64
+	BookID     int64
+	ShelfID    sql.NullInt64
+	Condition  string
+	AcquiredAt sql.NullTime
+	LentTo     sql.NullString
+	LentAt     sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 type Highlight struct {
@@ -40,12 +107,58 @@ type Highlight struct {
 	Image     sql.NullString
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt sql.NullTime
+}
+
+type MetadataJob struct {
+	ID         int64
+	UserID     int64
+	Status     string
+	LastBookID int64
+	Processed  int32
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type MetadataLookup struct {
+	ID        int64
+	Provider  string
+	Query     string
+	Results   json.RawMessage
+	CreatedAt time.Time
+}
+
+type MetadataSuggestion struct {
+	BookID    int64
+	Metadata  json.RawMessage
+	CreatedAt time.Time
+}
+
+type Note struct {
+	ID          int64
+	BookID      int64
+	HighlightID sql.NullInt64
+	PageFrom    sql.NullInt32
+	PageTo      sql.NullInt32
+	Content     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type SchemaMigration struct {
 	Version string
 }
 
+type Series struct {
+	ID             int64
+	UserID         int64
+	Name           string
+	Volumes        sql.NullInt32
+	GoogleSeriesID sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
 type Shelf struct {
 	ID        int64
 	Name      string
@@ -53,6 +166,15 @@ type Shelf struct {
 	UpdatedAt time.Time
 	UserID    int64
 	Position  int32
+	DeletedAt sql.NullTime
+}
+
+type Tag struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type User struct {
@@ -72,4 +194,39 @@ type User struct {
 	Whatsapp           sql.NullString
 	Telegram           sql.NullString
 	AmazonAssociatesID sql.NullString
+	Admin              bool
+}
+
+type Wish struct {
+	ID             int64
+	UserID         int64
+	Isbn           string
+	Title          string
+	Subtitle       string
+	Author         string
+	Description    string
+	Publisher      string
+	PageCount      int32
+	GoogleBooksID  sql.NullString
+	SeriesName     string
+	SeriesPosition sql.NullInt32
+	Priority       int16
+	Note           string
+	Source         string
+	ReservedBy     sql.NullString
+	ReservedAt     sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ReserverID     sql.NullInt64
+	ReserverToken  sql.NullString
+}
+
+type Work struct {
+	ID             int64
+	UserID         int64
+	Title          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	SeriesID       sql.NullInt64
+	SeriesPosition sql.NullInt32
 }
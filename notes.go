@@ -0,0 +1,18 @@
+package main
+
+import "strings"
+
+// NoteFromRequest reads the note form, notes are only shown to the book owner
+func NoteFromRequest(r Request) UpdateNoteParams {
+	return UpdateNoteParams{
+		HighlightID: NullInt64(r.FormValue("highlight_id")),
+		PageFrom:    NullInt32(r.FormValue("page_from")),
+		PageTo:      NullInt32(r.FormValue("page_to")),
+		Content:     strings.TrimSpace(r.FormValue("content")),
+	}
+}
+
+// EscapeLike escapes the LIKE wildcards so they match literally
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
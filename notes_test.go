@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"dune":       "dune",
+		"100%":       `100\%`,
+		"snake_case": `snake\_case`,
+		`C:\books`:   `C:\\books`,
+		`\%_`:        `\\\%\_`,
+	}
+
+	for query, expected := range tests {
+		if escaped := EscapeLike(query); escaped != expected {
+			t.Errorf("%q: expected %q, got %q", query, expected, escaped)
+		}
+	}
+}
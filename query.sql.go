@@ -6,162 +6,175 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
-const bookByIsbnAndUser = `-- name: BookByIsbnAndUser :one
-SELECT books.id, books.title, books.author, books.image, books.isbn, books.created_at, books.updated_at, books.shelf_id, books.user_id, books.google_books_id, books.subtitle, books.description, books.page_count, books.publisher, books.page_read, slug, shelves.name shelf_name
-  FROM users, books
-       LEFT JOIN shelves
-           ON shelves.id = books.shelf_id
- WHERE users.id = books.user_id
-   AND books.user_id = $1
-   AND isbn = $2
- LIMIT 1
+const auditLogByIDAndBook = `-- name: AuditLogByIDAndBook :one
+SELECT id, actor_id, book_id, entity, entity_id, action, changes, created_at, user_id, book_title FROM audit_logs WHERE id = $1 AND book_id = $2 LIMIT 1
 `
 
-type BookByIsbnAndUserParams struct {
-	UserID int64
-	Isbn   string
-}
-
-type BookByIsbnAndUserRow struct {
-	ID            int64
-	Title         string
-	Author        string
-	Image         sql.NullString
-	Isbn          string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	ShelfID       sql.NullInt64
-	UserID        int64
-	GoogleBooksID sql.NullString
-	Subtitle      string
-	Description   string
-	PageCount     int32
-	Publisher     string
-	PageRead      int32
-	Slug          string
-	ShelfName     sql.NullString
+type AuditLogByIDAndBookParams struct {
+	ID     int64
+	BookID int64
 }
 
-func (q *Queries) BookByIsbnAndUser(ctx context.Context, arg BookByIsbnAndUserParams) (BookByIsbnAndUserRow, error) {
-	row := q.db.QueryRowContext(ctx, bookByIsbnAndUser, arg.UserID, arg.Isbn)
-	var i BookByIsbnAndUserRow
+func (q *Queries) AuditLogByIDAndBook(ctx context.Context, arg AuditLogByIDAndBookParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, auditLogByIDAndBook, arg.ID, arg.BookID)
+	var i AuditLog
 	err := row.Scan(
 		&i.ID,
-		&i.Title,
-		&i.Author,
-		&i.Image,
-		&i.Isbn,
+		&i.ActorID,
+		&i.BookID,
+		&i.Entity,
+		&i.EntityID,
+		&i.Action,
+		&i.Changes,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ShelfID,
 		&i.UserID,
-		&i.GoogleBooksID,
-		&i.Subtitle,
-		&i.Description,
-		&i.PageCount,
-		&i.Publisher,
-		&i.PageRead,
-		&i.Slug,
-		&i.ShelfName,
+		&i.BookTitle,
 	)
 	return i, err
 }
 
-const booksCount = `-- name: BooksCount :one
-SELECT count(*) FROM books WHERE user_id = $1
-`
-
-func (q *Queries) BooksCount(ctx context.Context, userID int64) (int64, error) {
-	row := q.db.QueryRowContext(ctx, booksCount, userID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
-const completeBook = `-- name: CompleteBook :exec
-UPDATE books SET page_read = page_count WHERE id = $1
+const authorBooks = `-- name: AuthorBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, role
+  FROM book_authors, books, users
+ WHERE books.id = book_authors.book_id
+   AND users.id = books.user_id
+   AND author_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY title
 `
 
-func (q *Queries) CompleteBook(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, completeBook, id)
-	return err
+type AuthorBooksRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageRead      int32
+	PageCount     int32
+	Role          string
 }
 
-const deleteBook = `-- name: DeleteBook :exec
-DELETE FROM books WHERE id = $1
-`
-
-func (q *Queries) DeleteBook(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteBook, id)
-	return err
+func (q *Queries) AuthorBooks(ctx context.Context, authorID int64) ([]AuthorBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, authorBooks, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuthorBooksRow
+	for rows.Next() {
+		var i AuthorBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const deleteHighlight = `-- name: DeleteHighlight :exec
-DELETE FROM highlights WHERE id = $1
+const authorByIDAndUser = `-- name: AuthorByIDAndUser :one
+SELECT id, user_id, name, created_at, updated_at FROM authors WHERE id = $1 AND user_id = $2 LIMIT 1
 `
 
-func (q *Queries) DeleteHighlight(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteHighlight, id)
-	return err
+type AuthorByIDAndUserParams struct {
+	ID     int64
+	UserID int64
 }
 
-const deleteShelf = `-- name: DeleteShelf :exec
-DELETE FROM shelves WHERE id = $1
-`
-
-func (q *Queries) DeleteShelf(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteShelf, id)
-	return err
+func (q *Queries) AuthorByIDAndUser(ctx context.Context, arg AuthorByIDAndUserParams) (Author, error) {
+	row := q.db.QueryRowContext(ctx, authorByIDAndUser, arg.ID, arg.UserID)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const highlightByIDAndBook = `-- name: HighlightByIDAndBook :one
-SELECT id, book_id, page, content, image, created_at, updated_at FROM highlights WHERE id = $1 AND book_id = $2 LIMIT 1
+const authorByNameAndUser = `-- name: AuthorByNameAndUser :one
+SELECT id, user_id, name, created_at, updated_at FROM authors WHERE name = $1 AND user_id = $2 LIMIT 1
 `
 
-type HighlightByIDAndBookParams struct {
-	ID     int64
-	BookID int64
+type AuthorByNameAndUserParams struct {
+	Name   string
+	UserID int64
 }
 
-func (q *Queries) HighlightByIDAndBook(ctx context.Context, arg HighlightByIDAndBookParams) (Highlight, error) {
-	row := q.db.QueryRowContext(ctx, highlightByIDAndBook, arg.ID, arg.BookID)
-	var i Highlight
+func (q *Queries) AuthorByNameAndUser(ctx context.Context, arg AuthorByNameAndUserParams) (Author, error) {
+	row := q.db.QueryRowContext(ctx, authorByNameAndUser, arg.Name, arg.UserID)
+	var i Author
 	err := row.Scan(
 		&i.ID,
-		&i.BookID,
-		&i.Page,
-		&i.Content,
-		&i.Image,
+		&i.UserID,
+		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const highlights = `-- name: Highlights :many
-SELECT id, book_id, page, content, image, created_at, updated_at FROM highlights WHERE book_id = $1 ORDER BY page
+const authors = `-- name: Authors :many
+SELECT authors.id, authors.user_id, authors.name, authors.created_at, authors.updated_at, count(books.id) books_count
+  FROM authors
+       LEFT JOIN book_authors
+           ON book_authors.author_id = authors.id
+       LEFT JOIN books
+           ON books.id = book_authors.book_id
+          AND books.deleted_at IS NULL
+ WHERE authors.user_id = $1
+ GROUP BY authors.id
+HAVING count(books.id) > 0
+ ORDER BY name
 `
 
-func (q *Queries) Highlights(ctx context.Context, bookID int64) ([]Highlight, error) {
-	rows, err := q.db.QueryContext(ctx, highlights, bookID)
+type AuthorsRow struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	BooksCount int64
+}
+
+func (q *Queries) Authors(ctx context.Context, userID int64) ([]AuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, authors, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Highlight
+	var items []AuthorsRow
 	for rows.Next() {
-		var i Highlight
+		var i AuthorsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.BookID,
-			&i.Page,
-			&i.Content,
-			&i.Image,
+			&i.UserID,
+			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.BooksCount,
 		); err != nil {
 			return nil, err
 		}
@@ -176,23 +189,54 @@ func (q *Queries) Highlights(ctx context.Context, bookID int64) ([]Highlight, er
 	return items, nil
 }
 
-const highlightsWithImages = `-- name: HighlightsWithImages :many
-SELECT image FROM highlights WHERE image IS NOT NULL AND length(image) > 0 AND book_id = $1
+const bookAuditLogs = `-- name: BookAuditLogs :many
+SELECT audit_logs.id, audit_logs.actor_id, audit_logs.book_id, audit_logs.entity, audit_logs.entity_id, audit_logs.action, audit_logs.changes, audit_logs.created_at, audit_logs.user_id, audit_logs.book_title, users.name actor_name
+  FROM audit_logs
+       LEFT JOIN users
+           ON users.id = audit_logs.actor_id
+ WHERE book_id = $1
+ ORDER BY audit_logs.created_at DESC, audit_logs.id DESC
 `
 
-func (q *Queries) HighlightsWithImages(ctx context.Context, bookID int64) ([]sql.NullString, error) {
-	rows, err := q.db.QueryContext(ctx, highlightsWithImages, bookID)
+type BookAuditLogsRow struct {
+	ID        int64
+	ActorID   sql.NullInt64
+	BookID    int64
+	Entity    string
+	EntityID  int64
+	Action    string
+	Changes   json.RawMessage
+	CreatedAt time.Time
+	UserID    int64
+	BookTitle string
+	ActorName sql.NullString
+}
+
+func (q *Queries) BookAuditLogs(ctx context.Context, bookID int64) ([]BookAuditLogsRow, error) {
+	rows, err := q.db.QueryContext(ctx, bookAuditLogs, bookID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []sql.NullString
+	var items []BookAuditLogsRow
 	for rows.Next() {
-		var image sql.NullString
-		if err := rows.Scan(&image); err != nil {
+		var i BookAuditLogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.BookID,
+			&i.Entity,
+			&i.EntityID,
+			&i.Action,
+			&i.Changes,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.BookTitle,
+			&i.ActorName,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, image)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -203,92 +247,53 @@ func (q *Queries) HighlightsWithImages(ctx context.Context, bookID int64) ([]sql
 	return items, nil
 }
 
-const moveBookToShelf = `-- name: MoveBookToShelf :exec
-UPDATE books SET shelf_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
-`
-
-type MoveBookToShelfParams struct {
-	ShelfID sql.NullInt64
-	ID      int64
-}
-
-func (q *Queries) MoveBookToShelf(ctx context.Context, arg MoveBookToShelfParams) error {
-	_, err := q.db.ExecContext(ctx, moveBookToShelf, arg.ShelfID, arg.ID)
-	return err
-}
-
-const moveShelfDown = `-- name: MoveShelfDown :exec
-UPDATE shelves
-   SET position = (
-     CASE
-     WHEN position = (SELECT position FROM shelves WHERE shelves.id = $1) THEN position + 1
-     WHEN position = (SELECT position + 1 FROM shelves WHERE shelves.id = $1) THEN position - 1
-     END
-   )
- WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
-   AND position IN (
-     (SELECT position FROM shelves WHERE shelves.id = $1),
-     (SELECT position + 1 FROM shelves WHERE shelves.id = $1)
-   )
+const bookAuthors = `-- name: BookAuthors :many
+SELECT authors.id id, name, role
+  FROM book_authors, authors
+ WHERE authors.id = book_authors.author_id
+   AND book_id = $1
+ ORDER BY position
 `
 
-func (q *Queries) MoveShelfDown(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, moveShelfDown, id)
-	return err
+type BookAuthorsRow struct {
+	ID   int64
+	Name string
+	Role string
 }
 
-const moveShelfUp = `-- name: MoveShelfUp :exec
-UPDATE shelves
-   SET position = (
-     CASE
-     WHEN position = (SELECT position -1 FROM shelves WHERE shelves.id = $1) THEN position + 1
-     WHEN position = (SELECT position FROM shelves WHERE shelves.id = $1) THEN position - 1
-     END
-   )
- WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
-   AND position IN (
-     (SELECT position -1 FROM shelves WHERE shelves.id = $1),
-     (SELECT position FROM shelves WHERE shelves.id = $1)
-   )
-`
-
-func (q *Queries) MoveShelfUp(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, moveShelfUp, id)
-	return err
+func (q *Queries) BookAuthors(ctx context.Context, bookID int64) ([]BookAuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, bookAuthors, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BookAuthorsRow
+	for rows.Next() {
+		var i BookAuthorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const newBook = `-- name: NewBook :one
-INSERT INTO books (title, isbn, author, subtitle, description, publisher, page_count, google_books_id, user_id, page_read)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-       RETURNING id, title, author, image, isbn, created_at, updated_at, shelf_id, user_id, google_books_id, subtitle, description, page_count, publisher, page_read
+const bookByID = `-- name: BookByID :one
+SELECT id, title, author, image, isbn, created_at, updated_at, user_id, google_books_id, subtitle, description, page_count, publisher, page_read, work_id, rating, review, deleted_at FROM books WHERE id = $1 LIMIT 1
 `
 
-type NewBookParams struct {
-	Title         string
-	Isbn          string
-	Author        string
-	Subtitle      string
-	Description   string
-	Publisher     string
-	PageCount     int32
-	GoogleBooksID sql.NullString
-	UserID        int64
-	PageRead      int32
-}
-
-func (q *Queries) NewBook(ctx context.Context, arg NewBookParams) (Book, error) {
-	row := q.db.QueryRowContext(ctx, newBook,
-		arg.Title,
-		arg.Isbn,
-		arg.Author,
-		arg.Subtitle,
-		arg.Description,
-		arg.Publisher,
-		arg.PageCount,
-		arg.GoogleBooksID,
-		arg.UserID,
-		arg.PageRead,
-	)
+func (q *Queries) BookByID(ctx context.Context, id int64) (Book, error) {
+	row := q.db.QueryRowContext(ctx, bookByID, id)
 	var i Book
 	err := row.Scan(
 		&i.ID,
@@ -298,7 +303,6 @@ func (q *Queries) NewBook(ctx context.Context, arg NewBookParams) (Book, error)
 		&i.Isbn,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ShelfID,
 		&i.UserID,
 		&i.GoogleBooksID,
 		&i.Subtitle,
@@ -306,102 +310,216 @@ func (q *Queries) NewBook(ctx context.Context, arg NewBookParams) (Book, error)
 		&i.PageCount,
 		&i.Publisher,
 		&i.PageRead,
+		&i.WorkID,
+		&i.Rating,
+		&i.Review,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const newHighlight = `-- name: NewHighlight :one
-INSERT INTO highlights (book_id, page, content) VALUES ($1, $2, $3) RETURNING id, book_id, page, content, image, created_at, updated_at
+const bookByIsbnAndUser = `-- name: BookByIsbnAndUser :one
+SELECT books.id, books.title, books.author, books.image, books.isbn, books.created_at, books.updated_at, books.user_id, books.google_books_id, books.subtitle, books.description, books.page_count, books.publisher, books.page_read, books.work_id, books.rating, books.review, books.deleted_at, slug, works.title work_title, works.series_id, works.series_position, series.name series_name
+  FROM users, books, works
+       LEFT JOIN series
+           ON series.id = works.series_id
+ WHERE users.id = books.user_id
+   AND works.id = books.work_id
+   AND books.user_id = $1
+   AND isbn = $2
+   AND books.deleted_at IS NULL
+ LIMIT 1
 `
 
-type NewHighlightParams struct {
-	BookID  int64
-	Page    int32
-	Content string
-}
+type BookByIsbnAndUserParams struct {
+	UserID int64
+	Isbn   string
+}
 
-func (q *Queries) NewHighlight(ctx context.Context, arg NewHighlightParams) (Highlight, error) {
-	row := q.db.QueryRowContext(ctx, newHighlight, arg.BookID, arg.Page, arg.Content)
-	var i Highlight
+type BookByIsbnAndUserRow struct {
+	ID             int64
+	Title          string
+	Author         string
+	Image          sql.NullString
+	Isbn           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         int64
+	GoogleBooksID  sql.NullString
+	Subtitle       string
+	Description    string
+	PageCount      int32
+	Publisher      string
+	PageRead       int32
+	WorkID         int64
+	Rating         sql.NullInt16
+	Review         string
+	DeletedAt      sql.NullTime
+	Slug           string
+	WorkTitle      string
+	SeriesID       sql.NullInt64
+	SeriesPosition sql.NullInt32
+	SeriesName     sql.NullString
+}
+
+func (q *Queries) BookByIsbnAndUser(ctx context.Context, arg BookByIsbnAndUserParams) (BookByIsbnAndUserRow, error) {
+	row := q.db.QueryRowContext(ctx, bookByIsbnAndUser, arg.UserID, arg.Isbn)
+	var i BookByIsbnAndUserRow
 	err := row.Scan(
 		&i.ID,
-		&i.BookID,
-		&i.Page,
-		&i.Content,
+		&i.Title,
+		&i.Author,
 		&i.Image,
+		&i.Isbn,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.GoogleBooksID,
+		&i.Subtitle,
+		&i.Description,
+		&i.PageCount,
+		&i.Publisher,
+		&i.PageRead,
+		&i.WorkID,
+		&i.Rating,
+		&i.Review,
+		&i.DeletedAt,
+		&i.Slug,
+		&i.WorkTitle,
+		&i.SeriesID,
+		&i.SeriesPosition,
+		&i.SeriesName,
 	)
 	return i, err
 }
 
-const newShelf = `-- name: NewShelf :exec
-INSERT INTO shelves (name, user_id, position)
-VALUES ($1, $2, (
-  SELECT coalesce(MAX(position), 0) + 1
-    FROM shelves
-   WHERE user_id = $2)
-)
+const bookCopies = `-- name: BookCopies :many
+SELECT copies.id, copies.book_id, copies.shelf_id, copies.condition, copies.acquired_at, copies.lent_to, copies.lent_at, copies.created_at, copies.updated_at, shelves.name shelf_name
+  FROM copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE book_id = $1
+ ORDER BY copies.id
 `
 
-type NewShelfParams struct {
-	Name   string
-	UserID int64
+type BookCopiesRow struct {
+	ID         int64
+	BookID     int64
+	ShelfID    sql.NullInt64
+	Condition  string
+	AcquiredAt sql.NullTime
+	LentTo     sql.NullString
+	LentAt     sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ShelfName  sql.NullString
 }
 
-func (q *Queries) NewShelf(ctx context.Context, arg NewShelfParams) error {
-	_, err := q.db.ExecContext(ctx, newShelf, arg.Name, arg.UserID)
-	return err
+func (q *Queries) BookCopies(ctx context.Context, bookID int64) ([]BookCopiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, bookCopies, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BookCopiesRow
+	for rows.Next() {
+		var i BookCopiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.ShelfID,
+			&i.Condition,
+			&i.AcquiredAt,
+			&i.LentTo,
+			&i.LentAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ShelfName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const removeShelf = `-- name: RemoveShelf :exec
-UPDATE shelves SET position = position - 1
- WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
-   AND position > (SELECT position FROM shelves WHERE shelves.id = $1)
+const bookImages = `-- name: BookImages :many
+SELECT image FROM books WHERE image IS NOT NULL AND length(image) > 0
 `
 
-func (q *Queries) RemoveShelf(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, removeShelf, id)
-	return err
+func (q *Queries) BookImages(ctx context.Context) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, bookImages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var image sql.NullString
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		items = append(items, image)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const shelfBooks = `-- name: ShelfBooks :many
-SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count
-  FROM books, users
- WHERE users.id = books.user_id
-   AND shelf_id = $1
- ORDER BY books.created_at DESC
+const bookNotes = `-- name: BookNotes :many
+SELECT notes.id, notes.book_id, notes.highlight_id, notes.page_from, notes.page_to, notes.content, notes.created_at, notes.updated_at, highlights.page highlight_page, highlights.content highlight_content
+  FROM notes
+       LEFT JOIN highlights
+           ON highlights.id = notes.highlight_id
+          AND highlights.deleted_at IS NULL
+ WHERE notes.book_id = $1
+ ORDER BY coalesce(notes.page_from, highlights.page) NULLS LAST, notes.created_at
 `
 
-type ShelfBooksRow struct {
-	ID            int64
-	Title         string
-	Image         sql.NullString
-	GoogleBooksID sql.NullString
-	Slug          string
-	Isbn          string
-	PageRead      int32
-	PageCount     int32
+type BookNotesRow struct {
+	ID               int64
+	BookID           int64
+	HighlightID      sql.NullInt64
+	PageFrom         sql.NullInt32
+	PageTo           sql.NullInt32
+	Content          string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	HighlightPage    sql.NullInt32
+	HighlightContent sql.NullString
 }
 
-func (q *Queries) ShelfBooks(ctx context.Context, shelfID sql.NullInt64) ([]ShelfBooksRow, error) {
-	rows, err := q.db.QueryContext(ctx, shelfBooks, shelfID)
+func (q *Queries) BookNotes(ctx context.Context, bookID int64) ([]BookNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, bookNotes, bookID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ShelfBooksRow
+	var items []BookNotesRow
 	for rows.Next() {
-		var i ShelfBooksRow
+		var i BookNotesRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
-			&i.Image,
-			&i.GoogleBooksID,
-			&i.Slug,
-			&i.Isbn,
-			&i.PageRead,
-			&i.PageCount,
+			&i.BookID,
+			&i.HighlightID,
+			&i.PageFrom,
+			&i.PageTo,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HighlightPage,
+			&i.HighlightContent,
 		); err != nil {
 			return nil, err
 		}
@@ -416,49 +534,29 @@ func (q *Queries) ShelfBooks(ctx context.Context, shelfID sql.NullInt64) ([]Shel
 	return items, nil
 }
 
-const shelfByIdAndUser = `-- name: ShelfByIdAndUser :one
-SELECT id, name, created_at, updated_at, user_id, position FROM shelves WHERE user_id = $1 AND id = $2 LIMIT 1
-`
-
-type ShelfByIdAndUserParams struct {
-	UserID int64
-	ID     int64
-}
-
-func (q *Queries) ShelfByIdAndUser(ctx context.Context, arg ShelfByIdAndUserParams) (Shelf, error) {
-	row := q.db.QueryRowContext(ctx, shelfByIdAndUser, arg.UserID, arg.ID)
-	var i Shelf
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.UserID,
-		&i.Position,
-	)
-	return i, err
-}
-
-const shelves = `-- name: Shelves :many
-SELECT id, name, created_at, updated_at, user_id, position FROM shelves WHERE user_id = $1 ORDER BY position
+const bookTags = `-- name: BookTags :many
+SELECT tags.id, tags.user_id, tags.name, tags.created_at, tags.updated_at
+  FROM book_tags, tags
+ WHERE tags.id = book_tags.tag_id
+   AND book_id = $1
+ ORDER BY name
 `
 
-func (q *Queries) Shelves(ctx context.Context, userID int64) ([]Shelf, error) {
-	rows, err := q.db.QueryContext(ctx, shelves, userID)
+func (q *Queries) BookTags(ctx context.Context, bookID int64) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, bookTags, bookID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Shelf
+	var items []Tag
 	for rows.Next() {
-		var i Shelf
+		var i Tag
 		if err := rows.Scan(
 			&i.ID,
+			&i.UserID,
 			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.UserID,
-			&i.Position,
 		); err != nil {
 			return nil, err
 		}
@@ -473,103 +571,2279 @@ func (q *Queries) Shelves(ctx context.Context, userID int64) ([]Shelf, error) {
 	return items, nil
 }
 
-const signup = `-- name: Signup :one
-INSERT
- INTO users(name, image, slug, email)
-VALUES($1,$2,$3,$4)
-       ON CONFLICT (email)
-       DO UPDATE SET name = $1, image = $2, updated_at = CURRENT_TIMESTAMP
-       RETURNING id
-`
-
-type SignupParams struct {
-	Name  sql.NullString
-	Image sql.NullString
-	Slug  string
-	Email sql.NullString
-}
-
-func (q *Queries) Signup(ctx context.Context, arg SignupParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, signup,
-		arg.Name,
-		arg.Image,
-		arg.Slug,
-		arg.Email,
-	)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
-}
-
-const updateBook = `-- name: UpdateBook :exec
-UPDATE books
-   SET title = $1,
-       author = $2,
-       subtitle = $3,
-       description = $4,
-       publisher = $5,
-       page_count = $6,
-       page_read = $7,
-       updated_at = CURRENT_TIMESTAMP
- WHERE id = $8
+const booksCount = `-- name: BooksCount :one
+SELECT count(*) FROM books WHERE user_id = $1 AND deleted_at IS NULL
 `
 
-type UpdateBookParams struct {
-	Title       string
-	Author      string
-	Subtitle    string
-	Description string
-	Publisher   string
-	PageCount   int32
-	PageRead    int32
-	ID          int64
-}
-
-func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) error {
-	_, err := q.db.ExecContext(ctx, updateBook,
-		arg.Title,
-		arg.Author,
-		arg.Subtitle,
-		arg.Description,
-		arg.Publisher,
-		arg.PageCount,
-		arg.PageRead,
-		arg.ID,
-	)
-	return err
+func (q *Queries) BooksCount(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, booksCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-const updateBookImage = `-- name: UpdateBookImage :exec
-UPDATE books SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+const booksWithoutCover = `-- name: BooksWithoutCover :many
+SELECT id, google_books_id
+  FROM books
+ WHERE image IS NULL
+   AND google_books_id IS NOT NULL
+   AND deleted_at IS NULL
+   AND id > $1
+ ORDER BY id
+ LIMIT $2
 `
 
-type UpdateBookImageParams struct {
-	Image sql.NullString
+type BooksWithoutCoverParams struct {
 	ID    int64
+	Limit int32
 }
 
-func (q *Queries) UpdateBookImage(ctx context.Context, arg UpdateBookImageParams) error {
-	_, err := q.db.ExecContext(ctx, updateBookImage, arg.Image, arg.ID)
-	return err
-}
-
-const updateHighlight = `-- name: UpdateHighlight :exec
-UPDATE highlights SET page = $1, content = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3
-`
-
-type UpdateHighlightParams struct {
-	Page    int32
-	Content string
-	ID      int64
+type BooksWithoutCoverRow struct {
+	ID            int64
+	GoogleBooksID sql.NullString
 }
 
-func (q *Queries) UpdateHighlight(ctx context.Context, arg UpdateHighlightParams) error {
-	_, err := q.db.ExecContext(ctx, updateHighlight, arg.Page, arg.Content, arg.ID)
-	return err
+func (q *Queries) BooksWithoutCover(ctx context.Context, arg BooksWithoutCoverParams) ([]BooksWithoutCoverRow, error) {
+	rows, err := q.db.QueryContext(ctx, booksWithoutCover, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BooksWithoutCoverRow
+	for rows.Next() {
+		var i BooksWithoutCoverRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.GoogleBooksID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const updateHighlightImage = `-- name: UpdateHighlightImage :exec
-UPDATE highlights SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+const bulkAddBooks = `-- name: BulkAddBooks :many
+SELECT id, bulk_add_id, isbn, status, title, error FROM bulk_add_books WHERE bulk_add_id = $1 ORDER BY id
+`
+
+func (q *Queries) BulkAddBooks(ctx context.Context, bulkAddID int64) ([]BulkAddBook, error) {
+	rows, err := q.db.QueryContext(ctx, bulkAddBooks, bulkAddID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BulkAddBook
+	for rows.Next() {
+		var i BulkAddBook
+		if err := rows.Scan(
+			&i.ID,
+			&i.BulkAddID,
+			&i.Isbn,
+			&i.Status,
+			&i.Title,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const bulkAddByIDAndUser = `-- name: BulkAddByIDAndUser :one
+SELECT id, user_id, shelf_id, status, created_at, updated_at FROM bulk_adds WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type BulkAddByIDAndUserParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) BulkAddByIDAndUser(ctx context.Context, arg BulkAddByIDAndUserParams) (BulkAdd, error) {
+	row := q.db.QueryRowContext(ctx, bulkAddByIDAndUser, arg.ID, arg.UserID)
+	var i BulkAdd
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ShelfID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const completeBook = `-- name: CompleteBook :exec
+UPDATE books SET page_read = page_count WHERE id = $1
+`
+
+func (q *Queries) CompleteBook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, completeBook, id)
+	return err
+}
+
+const copyByIDAndBook = `-- name: CopyByIDAndBook :one
+SELECT id, book_id, shelf_id, condition, acquired_at, lent_to, lent_at, created_at, updated_at FROM copies WHERE id = $1 AND book_id = $2 LIMIT 1
+`
+
+type CopyByIDAndBookParams struct {
+	ID     int64
+	BookID int64
+}
+
+func (q *Queries) CopyByIDAndBook(ctx context.Context, arg CopyByIDAndBookParams) (Copy, error) {
+	row := q.db.QueryRowContext(ctx, copyByIDAndBook, arg.ID, arg.BookID)
+	var i Copy
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.ShelfID,
+		&i.Condition,
+		&i.AcquiredAt,
+		&i.LentTo,
+		&i.LentAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const copyByIDAndUser = `-- name: CopyByIDAndUser :one
+SELECT copies.id, copies.book_id, isbn
+  FROM copies, books
+ WHERE books.id = copies.book_id
+   AND books.user_id = $1
+   AND copies.id = $2
+   AND books.deleted_at IS NULL
+ LIMIT 1
+`
+
+type CopyByIDAndUserParams struct {
+	UserID int64
+	ID     int64
+}
+
+type CopyByIDAndUserRow struct {
+	ID     int64
+	BookID int64
+	Isbn   string
+}
+
+func (q *Queries) CopyByIDAndUser(ctx context.Context, arg CopyByIDAndUserParams) (CopyByIDAndUserRow, error) {
+	row := q.db.QueryRowContext(ctx, copyByIDAndUser, arg.UserID, arg.ID)
+	var i CopyByIDAndUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.Isbn,
+	)
+	return i, err
+}
+
+const copyShelfName = `-- name: CopyShelfName :one
+SELECT shelves.name
+  FROM copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE copies.id = $1
+ LIMIT 1
+`
+
+func (q *Queries) CopyShelfName(ctx context.Context, id int64) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, copyShelfName, id)
+	var name sql.NullString
+	err := row.Scan(&name)
+	return name, err
+}
+
+const deleteAuthor = `-- name: DeleteAuthor :exec
+DELETE FROM authors WHERE id = $1
+`
+
+func (q *Queries) DeleteAuthor(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAuthor, id)
+	return err
+}
+
+const deleteBookAuthors = `-- name: DeleteBookAuthors :exec
+DELETE FROM book_authors WHERE book_id = $1
+`
+
+func (q *Queries) DeleteBookAuthors(ctx context.Context, bookID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteBookAuthors, bookID)
+	return err
+}
+
+const deleteBookTags = `-- name: DeleteBookTags :exec
+DELETE FROM book_tags WHERE book_id = $1
+`
+
+func (q *Queries) DeleteBookTags(ctx context.Context, bookID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteBookTags, bookID)
+	return err
+}
+
+const deleteCopy = `-- name: DeleteCopy :exec
+DELETE FROM copies WHERE id = $1
+`
+
+func (q *Queries) DeleteCopy(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCopy, id)
+	return err
+}
+
+const deleteMetadataSuggestion = `-- name: DeleteMetadataSuggestion :exec
+DELETE FROM metadata_suggestions WHERE book_id = $1
+`
+
+func (q *Queries) DeleteMetadataSuggestion(ctx context.Context, bookID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteMetadataSuggestion, bookID)
+	return err
+}
+
+const deleteNote = `-- name: DeleteNote :exec
+DELETE FROM notes WHERE id = $1
+`
+
+func (q *Queries) DeleteNote(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNote, id)
+	return err
+}
+
+const deleteOrphanAuthors = `-- name: DeleteOrphanAuthors :exec
+DELETE FROM authors
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM book_authors WHERE book_authors.author_id = authors.id)
+`
+
+func (q *Queries) DeleteOrphanAuthors(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteOrphanAuthors, userID)
+	return err
+}
+
+const deleteOrphanSeries = `-- name: DeleteOrphanSeries :exec
+DELETE FROM series
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM works WHERE works.series_id = series.id)
+`
+
+func (q *Queries) DeleteOrphanSeries(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteOrphanSeries, userID)
+	return err
+}
+
+const deleteOrphanTags = `-- name: DeleteOrphanTags :exec
+DELETE FROM tags
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM book_tags WHERE book_tags.tag_id = tags.id)
+`
+
+func (q *Queries) DeleteOrphanTags(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteOrphanTags, userID)
+	return err
+}
+
+const deleteOrphanWorks = `-- name: DeleteOrphanWorks :exec
+DELETE FROM works
+ WHERE user_id = $1
+   AND NOT EXISTS (SELECT 1 FROM books WHERE books.work_id = works.id)
+`
+
+func (q *Queries) DeleteOrphanWorks(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteOrphanWorks, userID)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteWish = `-- name: DeleteWish :exec
+DELETE FROM wishes WHERE id = $1
+`
+
+func (q *Queries) DeleteWish(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWish, id)
+	return err
+}
+
+const expiredBookImages = `-- name: ExpiredBookImages :many
+SELECT image
+  FROM books
+ WHERE deleted_at < $1
+   AND image IS NOT NULL
+   AND length(image) > 0
+`
+
+func (q *Queries) ExpiredBookImages(ctx context.Context, deletedAt sql.NullTime) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, expiredBookImages, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var image sql.NullString
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		items = append(items, image)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expiredHighlightImages = `-- name: ExpiredHighlightImages :many
+SELECT highlights.image
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND (highlights.deleted_at < $1 OR books.deleted_at < $1)
+   AND highlights.image IS NOT NULL
+   AND length(highlights.image) > 0
+`
+
+func (q *Queries) ExpiredHighlightImages(ctx context.Context, deletedAt sql.NullTime) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, expiredHighlightImages, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var image sql.NullString
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		items = append(items, image)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const finishBulkAdd = `-- name: FinishBulkAdd :exec
+UPDATE bulk_adds SET status = 'done', updated_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) FinishBulkAdd(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, finishBulkAdd, id)
+	return err
+}
+
+const finishMetadataJob = `-- name: FinishMetadataJob :exec
+UPDATE metadata_jobs SET status = 'done', updated_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) FinishMetadataJob(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, finishMetadataJob, id)
+	return err
+}
+
+const googleBooksIDKnown = `-- name: GoogleBooksIDKnown :one
+SELECT EXISTS (SELECT 1 FROM books WHERE google_books_id = $1)
+    OR EXISTS (SELECT 1 FROM wishes WHERE google_books_id = $1)
+    OR EXISTS (
+         SELECT 1 FROM metadata_lookups
+          WHERE provider = 'google_books'
+            AND results @> jsonb_build_array(jsonb_build_object('GoogleBooksID', $1::varchar))
+       )
+`
+
+func (q *Queries) GoogleBooksIDKnown(ctx context.Context, googleBooksID sql.NullString) (bool, error) {
+	row := q.db.QueryRowContext(ctx, googleBooksIDKnown, googleBooksID)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const googleCoverIDs = `-- name: GoogleCoverIDs :many
+SELECT google_books_id FROM books WHERE google_books_id IS NOT NULL AND image IS NULL
+ UNION
+SELECT google_books_id FROM wishes WHERE google_books_id IS NOT NULL
+`
+
+func (q *Queries) GoogleCoverIDs(ctx context.Context) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, googleCoverIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var google_books_id sql.NullString
+		if err := rows.Scan(&google_books_id); err != nil {
+			return nil, err
+		}
+		items = append(items, google_books_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const highlightByIDAndBook = `-- name: HighlightByIDAndBook :one
+SELECT id, book_id, page, content, image, created_at, updated_at, deleted_at FROM highlights WHERE id = $1 AND book_id = $2 AND deleted_at IS NULL LIMIT 1
+`
+
+type HighlightByIDAndBookParams struct {
+	ID     int64
+	BookID int64
+}
+
+func (q *Queries) HighlightByIDAndBook(ctx context.Context, arg HighlightByIDAndBookParams) (Highlight, error) {
+	row := q.db.QueryRowContext(ctx, highlightByIDAndBook, arg.ID, arg.BookID)
+	var i Highlight
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.Page,
+		&i.Content,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const highlightImages = `-- name: HighlightImages :many
+SELECT image FROM highlights WHERE image IS NOT NULL AND length(image) > 0
+`
+
+func (q *Queries) HighlightImages(ctx context.Context) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, highlightImages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var image sql.NullString
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		items = append(items, image)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const highlights = `-- name: Highlights :many
+SELECT id, book_id, page, content, image, created_at, updated_at, deleted_at FROM highlights WHERE book_id = $1 AND deleted_at IS NULL ORDER BY page
+`
+
+func (q *Queries) Highlights(ctx context.Context, bookID int64) ([]Highlight, error) {
+	rows, err := q.db.QueryContext(ctx, highlights, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Highlight
+	for rows.Next() {
+		var i Highlight
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.Page,
+			&i.Content,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeAuthor = `-- name: MergeAuthor :exec
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT book_id, $1::bigint, role, position
+  FROM book_authors
+ WHERE author_id = $2
+    ON CONFLICT DO NOTHING
+`
+
+type MergeAuthorParams struct {
+	TargetID    int64
+	DuplicateID int64
+}
+
+func (q *Queries) MergeAuthor(ctx context.Context, arg MergeAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, mergeAuthor, arg.TargetID, arg.DuplicateID)
+	return err
+}
+
+const metadataJobByUser = `-- name: MetadataJobByUser :one
+SELECT id, user_id, status, last_book_id, processed, created_at, updated_at FROM metadata_jobs WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) MetadataJobByUser(ctx context.Context, userID int64) (MetadataJob, error) {
+	row := q.db.QueryRowContext(ctx, metadataJobByUser, userID)
+	var i MetadataJob
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.LastBookID,
+		&i.Processed,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const metadataJobProgress = `-- name: MetadataJobProgress :exec
+UPDATE metadata_jobs
+   SET last_book_id = $1,
+       processed = processed + 1,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $2
+`
+
+type MetadataJobProgressParams struct {
+	LastBookID int64
+	ID         int64
+}
+
+func (q *Queries) MetadataJobProgress(ctx context.Context, arg MetadataJobProgressParams) error {
+	_, err := q.db.ExecContext(ctx, metadataJobProgress, arg.LastBookID, arg.ID)
+	return err
+}
+
+const metadataLookup = `-- name: MetadataLookup :one
+SELECT id, provider, query, results, created_at FROM metadata_lookups
+ WHERE provider = $1
+   AND query = $2
+   AND created_at > $3
+ LIMIT 1
+`
+
+type MetadataLookupParams struct {
+	Provider  string
+	Query     string
+	CreatedAt time.Time
+}
+
+func (q *Queries) MetadataLookup(ctx context.Context, arg MetadataLookupParams) (MetadataLookup, error) {
+	row := q.db.QueryRowContext(ctx, metadataLookup, arg.Provider, arg.Query, arg.CreatedAt)
+	var i MetadataLookup
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.Query,
+		&i.Results,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const metadataSuggestion = `-- name: MetadataSuggestion :one
+SELECT book_id, metadata, created_at FROM metadata_suggestions WHERE book_id = $1 LIMIT 1
+`
+
+func (q *Queries) MetadataSuggestion(ctx context.Context, bookID int64) (MetadataSuggestion, error) {
+	row := q.db.QueryRowContext(ctx, metadataSuggestion, bookID)
+	var i MetadataSuggestion
+	err := row.Scan(
+		&i.BookID,
+		&i.Metadata,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const moveBookToWork = `-- name: MoveBookToWork :exec
+UPDATE books SET work_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type MoveBookToWorkParams struct {
+	WorkID int64
+	ID     int64
+}
+
+func (q *Queries) MoveBookToWork(ctx context.Context, arg MoveBookToWorkParams) error {
+	_, err := q.db.ExecContext(ctx, moveBookToWork, arg.WorkID, arg.ID)
+	return err
+}
+
+const moveCopyToShelf = `-- name: MoveCopyToShelf :exec
+UPDATE copies SET shelf_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type MoveCopyToShelfParams struct {
+	ShelfID sql.NullInt64
+	ID      int64
+}
+
+func (q *Queries) MoveCopyToShelf(ctx context.Context, arg MoveCopyToShelfParams) error {
+	_, err := q.db.ExecContext(ctx, moveCopyToShelf, arg.ShelfID, arg.ID)
+	return err
+}
+
+const moveShelfDown = `-- name: MoveShelfDown :exec
+UPDATE shelves
+   SET position = (
+     CASE
+     WHEN position = (SELECT position FROM shelves WHERE shelves.id = $1) THEN position + 1
+     WHEN position = (SELECT position + 1 FROM shelves WHERE shelves.id = $1) THEN position - 1
+     END
+   )
+ WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
+   AND position IN (
+     (SELECT position FROM shelves WHERE shelves.id = $1),
+     (SELECT position + 1 FROM shelves WHERE shelves.id = $1)
+   )
+   AND deleted_at IS NULL
+`
+
+func (q *Queries) MoveShelfDown(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, moveShelfDown, id)
+	return err
+}
+
+const moveShelfUp = `-- name: MoveShelfUp :exec
+UPDATE shelves
+   SET position = (
+     CASE
+     WHEN position = (SELECT position -1 FROM shelves WHERE shelves.id = $1) THEN position + 1
+     WHEN position = (SELECT position FROM shelves WHERE shelves.id = $1) THEN position - 1
+     END
+   )
+ WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
+   AND position IN (
+     (SELECT position -1 FROM shelves WHERE shelves.id = $1),
+     (SELECT position FROM shelves WHERE shelves.id = $1)
+   )
+   AND deleted_at IS NULL
+`
+
+func (q *Queries) MoveShelfUp(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, moveShelfUp, id)
+	return err
+}
+
+const newAuditLog = `-- name: NewAuditLog :exec
+INSERT INTO audit_logs (actor_id, book_id, user_id, book_title, entity, entity_id, action, changes)
+SELECT $1, id, user_id, title, $3, $4, $5, $6 FROM books WHERE id = $2
+`
+
+type NewAuditLogParams struct {
+	ActorID  sql.NullInt64
+	BookID   int64
+	Entity   string
+	EntityID int64
+	Action   string
+	Changes  json.RawMessage
+}
+
+func (q *Queries) NewAuditLog(ctx context.Context, arg NewAuditLogParams) error {
+	_, err := q.db.ExecContext(ctx, newAuditLog,
+		arg.ActorID,
+		arg.BookID,
+		arg.Entity,
+		arg.EntityID,
+		arg.Action,
+		arg.Changes,
+	)
+	return err
+}
+
+const newBook = `-- name: NewBook :one
+INSERT INTO books (title, isbn, author, subtitle, description, publisher, page_count, google_books_id, user_id, page_read, work_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+       RETURNING id, title, author, image, isbn, created_at, updated_at, user_id, google_books_id, subtitle, description, page_count, publisher, page_read, work_id, rating, review, deleted_at
+`
+
+type NewBookParams struct {
+	Title         string
+	Isbn          string
+	Author        string
+	Subtitle      string
+	Description   string
+	Publisher     string
+	PageCount     int32
+	GoogleBooksID sql.NullString
+	UserID        int64
+	PageRead      int32
+	WorkID        int64
+}
+
+func (q *Queries) NewBook(ctx context.Context, arg NewBookParams) (Book, error) {
+	row := q.db.QueryRowContext(ctx, newBook,
+		arg.Title,
+		arg.Isbn,
+		arg.Author,
+		arg.Subtitle,
+		arg.Description,
+		arg.Publisher,
+		arg.PageCount,
+		arg.GoogleBooksID,
+		arg.UserID,
+		arg.PageRead,
+		arg.WorkID,
+	)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Image,
+		&i.Isbn,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.GoogleBooksID,
+		&i.Subtitle,
+		&i.Description,
+		&i.PageCount,
+		&i.Publisher,
+		&i.PageRead,
+		&i.WorkID,
+		&i.Rating,
+		&i.Review,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const newBookAuthor = `-- name: NewBookAuthor :exec
+INSERT INTO book_authors (book_id, author_id, role, position)
+VALUES ($1, $2, $3, $4)
+       ON CONFLICT DO NOTHING
+`
+
+type NewBookAuthorParams struct {
+	BookID   int64
+	AuthorID int64
+	Role     string
+	Position int32
+}
+
+func (q *Queries) NewBookAuthor(ctx context.Context, arg NewBookAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, newBookAuthor,
+		arg.BookID,
+		arg.AuthorID,
+		arg.Role,
+		arg.Position,
+	)
+	return err
+}
+
+const newBookTag = `-- name: NewBookTag :exec
+INSERT INTO book_tags (book_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+`
+
+type NewBookTagParams struct {
+	BookID int64
+	TagID  int64
+}
+
+func (q *Queries) NewBookTag(ctx context.Context, arg NewBookTagParams) error {
+	_, err := q.db.ExecContext(ctx, newBookTag, arg.BookID, arg.TagID)
+	return err
+}
+
+const newBulkAdd = `-- name: NewBulkAdd :one
+INSERT INTO bulk_adds (user_id, shelf_id) VALUES ($1, $2) RETURNING id, user_id, shelf_id, status, created_at, updated_at
+`
+
+type NewBulkAddParams struct {
+	UserID  int64
+	ShelfID sql.NullInt64
+}
+
+func (q *Queries) NewBulkAdd(ctx context.Context, arg NewBulkAddParams) (BulkAdd, error) {
+	row := q.db.QueryRowContext(ctx, newBulkAdd, arg.UserID, arg.ShelfID)
+	var i BulkAdd
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ShelfID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const newBulkAddBook = `-- name: NewBulkAddBook :exec
+INSERT INTO bulk_add_books (bulk_add_id, isbn, status, error) VALUES ($1, $2, $3, $4)
+`
+
+type NewBulkAddBookParams struct {
+	BulkAddID int64
+	Isbn      string
+	Status    string
+	Error     string
+}
+
+func (q *Queries) NewBulkAddBook(ctx context.Context, arg NewBulkAddBookParams) error {
+	_, err := q.db.ExecContext(ctx, newBulkAddBook,
+		arg.BulkAddID,
+		arg.Isbn,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const newCopy = `-- name: NewCopy :one
+INSERT INTO copies (book_id, shelf_id) VALUES ($1, $2) RETURNING id, book_id, shelf_id, condition, acquired_at, lent_to, lent_at, created_at, updated_at
+`
+
+type NewCopyParams struct {
+	BookID  int64
+	ShelfID sql.NullInt64
+}
+
+func (q *Queries) NewCopy(ctx context.Context, arg NewCopyParams) (Copy, error) {
+	row := q.db.QueryRowContext(ctx, newCopy, arg.BookID, arg.ShelfID)
+	var i Copy
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.ShelfID,
+		&i.Condition,
+		&i.AcquiredAt,
+		&i.LentTo,
+		&i.LentAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const newHighlight = `-- name: NewHighlight :one
+INSERT INTO highlights (book_id, page, content) VALUES ($1, $2, $3) RETURNING id, book_id, page, content, image, created_at, updated_at, deleted_at
+`
+
+type NewHighlightParams struct {
+	BookID  int64
+	Page    int32
+	Content string
+}
+
+func (q *Queries) NewHighlight(ctx context.Context, arg NewHighlightParams) (Highlight, error) {
+	row := q.db.QueryRowContext(ctx, newHighlight, arg.BookID, arg.Page, arg.Content)
+	var i Highlight
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.Page,
+		&i.Content,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const newNote = `-- name: NewNote :one
+INSERT INTO notes (book_id, highlight_id, page_from, page_to, content)
+VALUES ($1, $2, $3, $4, $5)
+       RETURNING id, book_id, highlight_id, page_from, page_to, content, created_at, updated_at
+`
+
+type NewNoteParams struct {
+	BookID      int64
+	HighlightID sql.NullInt64
+	PageFrom    sql.NullInt32
+	PageTo      sql.NullInt32
+	Content     string
+}
+
+func (q *Queries) NewNote(ctx context.Context, arg NewNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, newNote,
+		arg.BookID,
+		arg.HighlightID,
+		arg.PageFrom,
+		arg.PageTo,
+		arg.Content,
+	)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.HighlightID,
+		&i.PageFrom,
+		&i.PageTo,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const newShelf = `-- name: NewShelf :exec
+INSERT INTO shelves (name, user_id, position)
+VALUES ($1, $2, (
+  SELECT coalesce(MAX(position), 0) + 1
+    FROM shelves
+   WHERE user_id = $2
+     AND deleted_at IS NULL)
+)
+`
+
+type NewShelfParams struct {
+	Name   string
+	UserID int64
+}
+
+func (q *Queries) NewShelf(ctx context.Context, arg NewShelfParams) error {
+	_, err := q.db.ExecContext(ctx, newShelf, arg.Name, arg.UserID)
+	return err
+}
+
+const newWish = `-- name: NewWish :one
+INSERT INTO wishes (user_id, isbn, title, subtitle, author, description, publisher, page_count, google_books_id, series_name, series_position, priority, note, source)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+       RETURNING id, user_id, isbn, title, subtitle, author, description, publisher, page_count, google_books_id, series_name, series_position, priority, note, source, reserved_by, reserved_at, created_at, updated_at, reserver_id, reserver_token
+`
+
+type NewWishParams struct {
+	UserID         int64
+	Isbn           string
+	Title          string
+	Subtitle       string
+	Author         string
+	Description    string
+	Publisher      string
+	PageCount      int32
+	GoogleBooksID  sql.NullString
+	SeriesName     string
+	SeriesPosition sql.NullInt32
+	Priority       int16
+	Note           string
+	Source         string
+}
+
+func (q *Queries) NewWish(ctx context.Context, arg NewWishParams) (Wish, error) {
+	row := q.db.QueryRowContext(ctx, newWish,
+		arg.UserID,
+		arg.Isbn,
+		arg.Title,
+		arg.Subtitle,
+		arg.Author,
+		arg.Description,
+		arg.Publisher,
+		arg.PageCount,
+		arg.GoogleBooksID,
+		arg.SeriesName,
+		arg.SeriesPosition,
+		arg.Priority,
+		arg.Note,
+		arg.Source,
+	)
+	var i Wish
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Isbn,
+		&i.Title,
+		&i.Subtitle,
+		&i.Author,
+		&i.Description,
+		&i.Publisher,
+		&i.PageCount,
+		&i.GoogleBooksID,
+		&i.SeriesName,
+		&i.SeriesPosition,
+		&i.Priority,
+		&i.Note,
+		&i.Source,
+		&i.ReservedBy,
+		&i.ReservedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReserverID,
+		&i.ReserverToken,
+	)
+	return i, err
+}
+
+const newWork = `-- name: NewWork :one
+INSERT INTO works (user_id, title) VALUES ($1, $2) RETURNING id, user_id, title, created_at, updated_at, series_id, series_position
+`
+
+type NewWorkParams struct {
+	UserID int64
+	Title  string
+}
+
+func (q *Queries) NewWork(ctx context.Context, arg NewWorkParams) (Work, error) {
+	row := q.db.QueryRowContext(ctx, newWork, arg.UserID, arg.Title)
+	var i Work
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SeriesID,
+		&i.SeriesPosition,
+	)
+	return i, err
+}
+
+const nextBookToEnrich = `-- name: NextBookToEnrich :one
+SELECT id, isbn
+  FROM books
+ WHERE user_id = $1
+   AND id > $2
+   AND deleted_at IS NULL
+   AND (description = '' OR publisher = '' OR page_count = 0 OR google_books_id IS NULL)
+ ORDER BY id
+ LIMIT 1
+`
+
+type NextBookToEnrichParams struct {
+	UserID int64
+	ID     int64
+}
+
+type NextBookToEnrichRow struct {
+	ID   int64
+	Isbn string
+}
+
+func (q *Queries) NextBookToEnrich(ctx context.Context, arg NextBookToEnrichParams) (NextBookToEnrichRow, error) {
+	row := q.db.QueryRowContext(ctx, nextBookToEnrich, arg.UserID, arg.ID)
+	var i NextBookToEnrichRow
+	err := row.Scan(
+		&i.ID,
+		&i.Isbn,
+	)
+	return i, err
+}
+
+const nextBulkAddBook = `-- name: NextBulkAddBook :one
+SELECT id, bulk_add_id, isbn, status, title, error FROM bulk_add_books
+ WHERE bulk_add_id = $1
+   AND status = 'waiting'
+ ORDER BY id
+ LIMIT 1
+`
+
+func (q *Queries) NextBulkAddBook(ctx context.Context, bulkAddID int64) (BulkAddBook, error) {
+	row := q.db.QueryRowContext(ctx, nextBulkAddBook, bulkAddID)
+	var i BulkAddBook
+	err := row.Scan(
+		&i.ID,
+		&i.BulkAddID,
+		&i.Isbn,
+		&i.Status,
+		&i.Title,
+		&i.Error,
+	)
+	return i, err
+}
+
+const noteByIDAndBook = `-- name: NoteByIDAndBook :one
+SELECT id, book_id, highlight_id, page_from, page_to, content, created_at, updated_at FROM notes WHERE id = $1 AND book_id = $2 LIMIT 1
+`
+
+type NoteByIDAndBookParams struct {
+	ID     int64
+	BookID int64
+}
+
+func (q *Queries) NoteByIDAndBook(ctx context.Context, arg NoteByIDAndBookParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, noteByIDAndBook, arg.ID, arg.BookID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.HighlightID,
+		&i.PageFrom,
+		&i.PageTo,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const ping = `-- name: Ping :exec
+SELECT 1
+`
+
+func (q *Queries) Ping(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, ping)
+	return err
+}
+
+const promoteUser = `-- name: PromoteUser :exec
+UPDATE users SET admin = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) PromoteUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, promoteUser, id)
+	return err
+}
+
+const purgeBooks = `-- name: PurgeBooks :many
+DELETE FROM books WHERE deleted_at < $1 RETURNING user_id
+`
+
+func (q *Queries) PurgeBooks(ctx context.Context, deletedAt sql.NullTime) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, purgeBooks, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var user_id int64
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeHighlights = `-- name: PurgeHighlights :exec
+DELETE FROM highlights WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeHighlights(ctx context.Context, deletedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, purgeHighlights, deletedAt)
+	return err
+}
+
+const purgeShelves = `-- name: PurgeShelves :exec
+DELETE FROM shelves WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeShelves(ctx context.Context, deletedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, purgeShelves, deletedAt)
+	return err
+}
+
+const refreshAuthorBooks = `-- name: RefreshAuthorBooks :exec
+UPDATE books
+   SET author = coalesce((
+     SELECT string_agg(
+              CASE role WHEN 'author' THEN name ELSE name || ' (' || role || ')' END,
+              ', ' ORDER BY position)
+       FROM book_authors, authors
+      WHERE authors.id = book_authors.author_id
+        AND book_authors.book_id = books.id
+   ), ''),
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = $1)
+`
+
+func (q *Queries) RefreshAuthorBooks(ctx context.Context, authorID int64) error {
+	_, err := q.db.ExecContext(ctx, refreshAuthorBooks, authorID)
+	return err
+}
+
+const refreshBookAuthor = `-- name: RefreshBookAuthor :exec
+UPDATE books
+   SET author = coalesce((
+     SELECT string_agg(
+              CASE role WHEN 'author' THEN name ELSE name || ' (' || role || ')' END,
+              ', ' ORDER BY position)
+       FROM book_authors, authors
+      WHERE authors.id = book_authors.author_id
+        AND book_authors.book_id = books.id
+   ), '')
+ WHERE id = $1
+`
+
+func (q *Queries) RefreshBookAuthor(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, refreshBookAuthor, id)
+	return err
+}
+
+const removeShelf = `-- name: RemoveShelf :exec
+UPDATE shelves SET position = position - 1
+ WHERE user_id = (SELECT user_id FROM shelves WHERE shelves.id = $1)
+   AND position > (SELECT position FROM shelves WHERE shelves.id = $1)
+   AND deleted_at IS NULL
+`
+
+func (q *Queries) RemoveShelf(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, removeShelf, id)
+	return err
+}
+
+const reserveWish = `-- name: ReserveWish :exec
+UPDATE wishes
+   SET reserved_by = $1,
+       reserver_id = $2,
+       reserver_token = $3,
+       reserved_at = CURRENT_TIMESTAMP
+ WHERE id = $4
+   AND reserved_by IS NULL
+`
+
+type ReserveWishParams struct {
+	ReservedBy    sql.NullString
+	ReserverID    sql.NullInt64
+	ReserverToken sql.NullString
+	ID            int64
+}
+
+func (q *Queries) ReserveWish(ctx context.Context, arg ReserveWishParams) error {
+	_, err := q.db.ExecContext(ctx, reserveWish,
+		arg.ReservedBy,
+		arg.ReserverID,
+		arg.ReserverToken,
+		arg.ID,
+	)
+	return err
+}
+
+const restoreBook = `-- name: RestoreBook :exec
+UPDATE books SET deleted_at = NULL WHERE id = $1
+`
+
+func (q *Queries) RestoreBook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, restoreBook, id)
+	return err
+}
+
+const restoreHighlight = `-- name: RestoreHighlight :one
+UPDATE highlights
+   SET deleted_at = NULL
+ WHERE id = $1
+   AND deleted_at IS NOT NULL
+   AND book_id IN (SELECT id FROM books WHERE user_id = $2 AND deleted_at IS NULL)
+       RETURNING id, book_id, page, content, image, created_at, updated_at, deleted_at
+`
+
+type RestoreHighlightParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) RestoreHighlight(ctx context.Context, arg RestoreHighlightParams) (Highlight, error) {
+	row := q.db.QueryRowContext(ctx, restoreHighlight, arg.ID, arg.UserID)
+	var i Highlight
+	err := row.Scan(
+		&i.ID,
+		&i.BookID,
+		&i.Page,
+		&i.Content,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreShelf = `-- name: RestoreShelf :one
+UPDATE shelves
+   SET deleted_at = NULL,
+       position = (
+         SELECT coalesce(MAX(position), 0) + 1
+           FROM shelves
+          WHERE user_id = $2
+            AND deleted_at IS NULL
+       )
+ WHERE id = $1
+   AND user_id = $2
+   AND deleted_at IS NOT NULL
+       RETURNING id
+`
+
+type RestoreShelfParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) RestoreShelf(ctx context.Context, arg RestoreShelfParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, restoreShelf, arg.ID, arg.UserID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const runningBulkAdds = `-- name: RunningBulkAdds :many
+SELECT id, user_id, shelf_id, status, created_at, updated_at FROM bulk_adds WHERE status = 'running'
+`
+
+func (q *Queries) RunningBulkAdds(ctx context.Context) ([]BulkAdd, error) {
+	rows, err := q.db.QueryContext(ctx, runningBulkAdds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BulkAdd
+	for rows.Next() {
+		var i BulkAdd
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ShelfID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const runningMetadataJobs = `-- name: RunningMetadataJobs :many
+SELECT id, user_id, status, last_book_id, processed, created_at, updated_at FROM metadata_jobs WHERE status = 'running'
+`
+
+func (q *Queries) RunningMetadataJobs(ctx context.Context) ([]MetadataJob, error) {
+	rows, err := q.db.QueryContext(ctx, runningMetadataJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MetadataJob
+	for rows.Next() {
+		var i MetadataJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.LastBookID,
+			&i.Processed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const saveMetadataLookup = `-- name: SaveMetadataLookup :exec
+INSERT INTO metadata_lookups (provider, query, results)
+VALUES ($1, $2, $3)
+    ON CONFLICT (provider, query)
+    DO UPDATE SET results = EXCLUDED.results, created_at = CURRENT_TIMESTAMP
+`
+
+type SaveMetadataLookupParams struct {
+	Provider string
+	Query    string
+	Results  json.RawMessage
+}
+
+func (q *Queries) SaveMetadataLookup(ctx context.Context, arg SaveMetadataLookupParams) error {
+	_, err := q.db.ExecContext(ctx, saveMetadataLookup, arg.Provider, arg.Query, arg.Results)
+	return err
+}
+
+const saveMetadataSuggestion = `-- name: SaveMetadataSuggestion :exec
+INSERT INTO metadata_suggestions (book_id, metadata)
+VALUES ($1, $2)
+    ON CONFLICT (book_id)
+    DO UPDATE SET metadata = EXCLUDED.metadata, created_at = CURRENT_TIMESTAMP
+`
+
+type SaveMetadataSuggestionParams struct {
+	BookID   int64
+	Metadata json.RawMessage
+}
+
+func (q *Queries) SaveMetadataSuggestion(ctx context.Context, arg SaveMetadataSuggestionParams) error {
+	_, err := q.db.ExecContext(ctx, saveMetadataSuggestion, arg.BookID, arg.Metadata)
+	return err
+}
+
+const searchNotes = `-- name: SearchNotes :many
+SELECT notes.id, notes.book_id, notes.highlight_id, notes.page_from, notes.page_to, notes.content, notes.created_at, notes.updated_at, books.title book_title, isbn
+  FROM notes, books
+ WHERE books.id = notes.book_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+   AND notes.content ILIKE '%' || $2::text || '%' ESCAPE '\'
+ ORDER BY notes.updated_at DESC
+`
+
+type SearchNotesParams struct {
+	UserID int64
+	Query  string
+}
+
+type SearchNotesRow struct {
+	ID          int64
+	BookID      int64
+	HighlightID sql.NullInt64
+	PageFrom    sql.NullInt32
+	PageTo      sql.NullInt32
+	Content     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	BookTitle   string
+	Isbn        string
+}
+
+func (q *Queries) SearchNotes(ctx context.Context, arg SearchNotesParams) ([]SearchNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotes, arg.UserID, arg.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchNotesRow
+	for rows.Next() {
+		var i SearchNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.HighlightID,
+			&i.PageFrom,
+			&i.PageTo,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.BookTitle,
+			&i.Isbn,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const seriesBooks = `-- name: SeriesBooks :many
+SELECT DISTINCT ON (works.series_position, works.id)
+       books.id id, books.title title, books.image image, google_books_id, slug, isbn, page_read, page_count, works.id work_id, works.series_position
+  FROM works, books, users
+ WHERE books.work_id = works.id
+   AND users.id = books.user_id
+   AND works.series_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY works.series_position, works.id, books.created_at
+`
+
+type SeriesBooksRow struct {
+	ID             int64
+	Title          string
+	Image          sql.NullString
+	GoogleBooksID  sql.NullString
+	Slug           string
+	Isbn           string
+	PageRead       int32
+	PageCount      int32
+	WorkID         int64
+	SeriesPosition sql.NullInt32
+}
+
+func (q *Queries) SeriesBooks(ctx context.Context, seriesID sql.NullInt64) ([]SeriesBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, seriesBooks, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SeriesBooksRow
+	for rows.Next() {
+		var i SeriesBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+			&i.WorkID,
+			&i.SeriesPosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const seriesByIDAndUser = `-- name: SeriesByIDAndUser :one
+SELECT id, user_id, name, volumes, google_series_id, created_at, updated_at FROM series WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type SeriesByIDAndUserParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) SeriesByIDAndUser(ctx context.Context, arg SeriesByIDAndUserParams) (Series, error) {
+	row := q.db.QueryRowContext(ctx, seriesByIDAndUser, arg.ID, arg.UserID)
+	var i Series
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Volumes,
+		&i.GoogleSeriesID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const seriesByNameAndUser = `-- name: SeriesByNameAndUser :one
+SELECT id, user_id, name, volumes, google_series_id, created_at, updated_at FROM series WHERE name = $1 AND user_id = $2 LIMIT 1
+`
+
+type SeriesByNameAndUserParams struct {
+	Name   string
+	UserID int64
+}
+
+func (q *Queries) SeriesByNameAndUser(ctx context.Context, arg SeriesByNameAndUserParams) (Series, error) {
+	row := q.db.QueryRowContext(ctx, seriesByNameAndUser, arg.Name, arg.UserID)
+	var i Series
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Volumes,
+		&i.GoogleSeriesID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setWorkSeries = `-- name: SetWorkSeries :exec
+UPDATE works
+   SET series_id = $1,
+       series_position = $2,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $3
+`
+
+type SetWorkSeriesParams struct {
+	SeriesID       sql.NullInt64
+	SeriesPosition sql.NullInt32
+	ID             int64
+}
+
+func (q *Queries) SetWorkSeries(ctx context.Context, arg SetWorkSeriesParams) error {
+	_, err := q.db.ExecContext(ctx, setWorkSeries, arg.SeriesID, arg.SeriesPosition, arg.ID)
+	return err
+}
+
+const shelfBooks = `-- name: ShelfBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, copies.id copy_id, rating
+  FROM copies, books, users
+ WHERE books.id = copies.book_id
+   AND users.id = books.user_id
+   AND copies.shelf_id = $1
+   AND books.deleted_at IS NULL
+   AND EXISTS (SELECT 1 FROM shelves WHERE shelves.id = copies.shelf_id AND shelves.deleted_at IS NULL)
+ ORDER BY CASE WHEN $2::boolean THEN rating END DESC NULLS LAST, copies.created_at DESC
+`
+
+type ShelfBooksParams struct {
+	ShelfID  sql.NullInt64
+	TopRated bool
+}
+
+type ShelfBooksRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageRead      int32
+	PageCount     int32
+	CopyID        int64
+	Rating        sql.NullInt16
+}
+
+func (q *Queries) ShelfBooks(ctx context.Context, arg ShelfBooksParams) ([]ShelfBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, shelfBooks, arg.ShelfID, arg.TopRated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShelfBooksRow
+	for rows.Next() {
+		var i ShelfBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+			&i.CopyID,
+			&i.Rating,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const shelfByIdAndUser = `-- name: ShelfByIdAndUser :one
+SELECT id, name, created_at, updated_at, user_id, position, deleted_at FROM shelves WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL LIMIT 1
+`
+
+type ShelfByIdAndUserParams struct {
+	UserID int64
+	ID     int64
+}
+
+func (q *Queries) ShelfByIdAndUser(ctx context.Context, arg ShelfByIdAndUserParams) (Shelf, error) {
+	row := q.db.QueryRowContext(ctx, shelfByIdAndUser, arg.UserID, arg.ID)
+	var i Shelf
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Position,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const shelves = `-- name: Shelves :many
+SELECT id, name, created_at, updated_at, user_id, position, deleted_at FROM shelves WHERE user_id = $1 AND deleted_at IS NULL ORDER BY position
+`
+
+func (q *Queries) Shelves(ctx context.Context, userID int64) ([]Shelf, error) {
+	rows, err := q.db.QueryContext(ctx, shelves, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Shelf
+	for rows.Next() {
+		var i Shelf
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Position,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const signup = `-- name: Signup :one
+INSERT
+ INTO users(name, image, slug, email)
+VALUES($1,$2,$3,$4)
+       ON CONFLICT (email)
+       DO UPDATE SET name = $1, image = $2, updated_at = CURRENT_TIMESTAMP
+       RETURNING id
+`
+
+type SignupParams struct {
+	Name  sql.NullString
+	Image sql.NullString
+	Slug  string
+	Email sql.NullString
+}
+
+func (q *Queries) Signup(ctx context.Context, arg SignupParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, signup,
+		arg.Name,
+		arg.Image,
+		arg.Slug,
+		arg.Email,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const startMetadataJob = `-- name: StartMetadataJob :one
+INSERT INTO metadata_jobs (user_id) VALUES ($1)
+    ON CONFLICT (user_id)
+    DO UPDATE SET status = 'running', last_book_id = 0, processed = 0, updated_at = CURRENT_TIMESTAMP
+       RETURNING id, user_id, status, last_book_id, processed, created_at, updated_at
+`
+
+func (q *Queries) StartMetadataJob(ctx context.Context, userID int64) (MetadataJob, error) {
+	row := q.db.QueryRowContext(ctx, startMetadataJob, userID)
+	var i MetadataJob
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.LastBookID,
+		&i.Processed,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const tagBooks = `-- name: TagBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, copies.id copy_id, shelves.id shelf_id
+  FROM book_tags, books, users, copies
+       LEFT JOIN shelves
+           ON shelves.id = copies.shelf_id
+          AND shelves.deleted_at IS NULL
+ WHERE books.id = book_tags.book_id
+   AND users.id = books.user_id
+   AND copies.book_id = books.id
+   AND tag_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY copies.created_at DESC
+`
+
+type TagBooksRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageRead      int32
+	PageCount     int32
+	CopyID        int64
+	ShelfID       sql.NullInt64
+}
+
+func (q *Queries) TagBooks(ctx context.Context, tagID int64) ([]TagBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, tagBooks, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TagBooksRow
+	for rows.Next() {
+		var i TagBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+			&i.CopyID,
+			&i.ShelfID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tagByNameAndUser = `-- name: TagByNameAndUser :one
+SELECT id, user_id, name, created_at, updated_at FROM tags WHERE name = $1 AND user_id = $2 LIMIT 1
+`
+
+type TagByNameAndUserParams struct {
+	Name   string
+	UserID int64
+}
+
+func (q *Queries) TagByNameAndUser(ctx context.Context, arg TagByNameAndUserParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, tagByNameAndUser, arg.Name, arg.UserID)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const trashBook = `-- name: TrashBook :exec
+UPDATE books SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) TrashBook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, trashBook, id)
+	return err
+}
+
+const trashHighlight = `-- name: TrashHighlight :exec
+UPDATE highlights SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) TrashHighlight(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, trashHighlight, id)
+	return err
+}
+
+const trashShelf = `-- name: TrashShelf :exec
+UPDATE shelves SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1
+`
+
+func (q *Queries) TrashShelf(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, trashShelf, id)
+	return err
+}
+
+const trashedBookByIDAndUser = `-- name: TrashedBookByIDAndUser :one
+SELECT id, title, author, image, isbn, created_at, updated_at, user_id, google_books_id, subtitle, description, page_count, publisher, page_read, work_id, rating, review, deleted_at FROM books WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL LIMIT 1
+`
+
+type TrashedBookByIDAndUserParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) TrashedBookByIDAndUser(ctx context.Context, arg TrashedBookByIDAndUserParams) (Book, error) {
+	row := q.db.QueryRowContext(ctx, trashedBookByIDAndUser, arg.ID, arg.UserID)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Image,
+		&i.Isbn,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.GoogleBooksID,
+		&i.Subtitle,
+		&i.Description,
+		&i.PageCount,
+		&i.Publisher,
+		&i.PageRead,
+		&i.WorkID,
+		&i.Rating,
+		&i.Review,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const trashedBooks = `-- name: TrashedBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, deleted_at
+  FROM books, users
+ WHERE users.id = books.user_id
+   AND books.user_id = $1
+   AND deleted_at IS NOT NULL
+ ORDER BY deleted_at DESC
+`
+
+type TrashedBooksRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageRead      int32
+	PageCount     int32
+	DeletedAt     sql.NullTime
+}
+
+func (q *Queries) TrashedBooks(ctx context.Context, userID int64) ([]TrashedBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, trashedBooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrashedBooksRow
+	for rows.Next() {
+		var i TrashedBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const trashedHighlights = `-- name: TrashedHighlights :many
+SELECT highlights.id, highlights.book_id, highlights.page, highlights.content, highlights.image, highlights.created_at, highlights.updated_at, highlights.deleted_at, books.title book_title, isbn
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+   AND highlights.deleted_at IS NOT NULL
+ ORDER BY highlights.deleted_at DESC
+`
+
+type TrashedHighlightsRow struct {
+	ID        int64
+	BookID    int64
+	Page      int32
+	Content   string
+	Image     sql.NullString
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt sql.NullTime
+	BookTitle string
+	Isbn      string
+}
+
+func (q *Queries) TrashedHighlights(ctx context.Context, userID int64) ([]TrashedHighlightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, trashedHighlights, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrashedHighlightsRow
+	for rows.Next() {
+		var i TrashedHighlightsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.Page,
+			&i.Content,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.BookTitle,
+			&i.Isbn,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const trashedShelves = `-- name: TrashedShelves :many
+SELECT id, name, created_at, updated_at, user_id, position, deleted_at FROM shelves WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC
+`
+
+func (q *Queries) TrashedShelves(ctx context.Context, userID int64) ([]Shelf, error) {
+	rows, err := q.db.QueryContext(ctx, trashedShelves, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Shelf
+	for rows.Next() {
+		var i Shelf
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Position,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unreserveWish = `-- name: UnreserveWish :exec
+UPDATE wishes SET reserved_by = NULL, reserver_id = NULL, reserver_token = NULL, reserved_at = NULL WHERE id = $1
+`
+
+func (q *Queries) UnreserveWish(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, unreserveWish, id)
+	return err
+}
+
+const updateAuthor = `-- name: UpdateAuthor :exec
+UPDATE authors SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type UpdateAuthorParams struct {
+	Name string
+	ID   int64
+}
+
+func (q *Queries) UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, updateAuthor, arg.Name, arg.ID)
+	return err
+}
+
+const updateBook = `-- name: UpdateBook :exec
+UPDATE books
+   SET title = $1,
+       author = $2,
+       subtitle = $3,
+       description = $4,
+       publisher = $5,
+       page_count = $6,
+       page_read = $7,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $8
+`
+
+type UpdateBookParams struct {
+	Title       string
+	Author      string
+	Subtitle    string
+	Description string
+	Publisher   string
+	PageCount   int32
+	PageRead    int32
+	ID          int64
+}
+
+func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) error {
+	_, err := q.db.ExecContext(ctx, updateBook,
+		arg.Title,
+		arg.Author,
+		arg.Subtitle,
+		arg.Description,
+		arg.Publisher,
+		arg.PageCount,
+		arg.PageRead,
+		arg.ID,
+	)
+	return err
+}
+
+const updateBookGoogleBooksID = `-- name: UpdateBookGoogleBooksID :exec
+UPDATE books SET google_books_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type UpdateBookGoogleBooksIDParams struct {
+	GoogleBooksID sql.NullString
+	ID            int64
+}
+
+func (q *Queries) UpdateBookGoogleBooksID(ctx context.Context, arg UpdateBookGoogleBooksIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateBookGoogleBooksID, arg.GoogleBooksID, arg.ID)
+	return err
+}
+
+const updateBookImage = `-- name: UpdateBookImage :exec
+UPDATE books SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type UpdateBookImageParams struct {
+	Image sql.NullString
+	ID    int64
+}
+
+func (q *Queries) UpdateBookImage(ctx context.Context, arg UpdateBookImageParams) error {
+	_, err := q.db.ExecContext(ctx, updateBookImage, arg.Image, arg.ID)
+	return err
+}
+
+const updateBookReview = `-- name: UpdateBookReview :exec
+UPDATE books
+   SET rating = $1,
+       review = $2,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $3
+`
+
+type UpdateBookReviewParams struct {
+	Rating sql.NullInt16
+	Review string
+	ID     int64
+}
+
+func (q *Queries) UpdateBookReview(ctx context.Context, arg UpdateBookReviewParams) error {
+	_, err := q.db.ExecContext(ctx, updateBookReview, arg.Rating, arg.Review, arg.ID)
+	return err
+}
+
+const updateBulkAddBook = `-- name: UpdateBulkAddBook :exec
+UPDATE bulk_add_books SET status = $1, title = $2, error = $3 WHERE id = $4
+`
+
+type UpdateBulkAddBookParams struct {
+	Status string
+	Title  string
+	Error  string
+	ID     int64
+}
+
+func (q *Queries) UpdateBulkAddBook(ctx context.Context, arg UpdateBulkAddBookParams) error {
+	_, err := q.db.ExecContext(ctx, updateBulkAddBook,
+		arg.Status,
+		arg.Title,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const updateCopy = `-- name: UpdateCopy :exec
+UPDATE copies
+   SET condition = $1,
+       acquired_at = $2,
+       lent_to = $3,
+       lent_at = $4,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $5
+`
+
+type UpdateCopyParams struct {
+	Condition  string
+	AcquiredAt sql.NullTime
+	LentTo     sql.NullString
+	LentAt     sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) UpdateCopy(ctx context.Context, arg UpdateCopyParams) error {
+	_, err := q.db.ExecContext(ctx, updateCopy,
+		arg.Condition,
+		arg.AcquiredAt,
+		arg.LentTo,
+		arg.LentAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateHighlight = `-- name: UpdateHighlight :exec
+UPDATE highlights SET page = $1, content = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3
+`
+
+type UpdateHighlightParams struct {
+	Page    int32
+	Content string
+	ID      int64
+}
+
+func (q *Queries) UpdateHighlight(ctx context.Context, arg UpdateHighlightParams) error {
+	_, err := q.db.ExecContext(ctx, updateHighlight, arg.Page, arg.Content, arg.ID)
+	return err
+}
+
+const updateHighlightImage = `-- name: UpdateHighlightImage :exec
+UPDATE highlights SET image = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
 `
 
 type UpdateHighlightImageParams struct {
@@ -577,43 +2851,673 @@ type UpdateHighlightImageParams struct {
 	ID    int64
 }
 
-func (q *Queries) UpdateHighlightImage(ctx context.Context, arg UpdateHighlightImageParams) error {
-	_, err := q.db.ExecContext(ctx, updateHighlightImage, arg.Image, arg.ID)
-	return err
+func (q *Queries) UpdateHighlightImage(ctx context.Context, arg UpdateHighlightImageParams) error {
+	_, err := q.db.ExecContext(ctx, updateHighlightImage, arg.Image, arg.ID)
+	return err
+}
+
+const updateNote = `-- name: UpdateNote :exec
+UPDATE notes
+   SET highlight_id = $1,
+       page_from = $2,
+       page_to = $3,
+       content = $4,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $5
+`
+
+type UpdateNoteParams struct {
+	HighlightID sql.NullInt64
+	PageFrom    sql.NullInt32
+	PageTo      sql.NullInt32
+	Content     string
+	ID          int64
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) error {
+	_, err := q.db.ExecContext(ctx, updateNote,
+		arg.HighlightID,
+		arg.PageFrom,
+		arg.PageTo,
+		arg.Content,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSeries = `-- name: UpdateSeries :exec
+UPDATE series SET name = $1, volumes = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3
+`
+
+type UpdateSeriesParams struct {
+	Name    string
+	Volumes sql.NullInt32
+	ID      int64
+}
+
+func (q *Queries) UpdateSeries(ctx context.Context, arg UpdateSeriesParams) error {
+	_, err := q.db.ExecContext(ctx, updateSeries, arg.Name, arg.Volumes, arg.ID)
+	return err
+}
+
+const updateShelf = `-- name: UpdateShelf :exec
+UPDATE shelves SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+`
+
+type UpdateShelfParams struct {
+	Name string
+	ID   int64
+}
+
+func (q *Queries) UpdateShelf(ctx context.Context, arg UpdateShelfParams) error {
+	_, err := q.db.ExecContext(ctx, updateShelf, arg.Name, arg.ID)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :exec
+UPDATE users
+   SET description = $1,
+       amazon_associates_id = $2,
+       facebook = $3,
+       twitter = $4,
+       linkedin = $5,
+       instagram = $6,
+       phone = $7,
+       whatsapp = $8,
+       telegram = $9,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $10
+`
+
+type UpdateUserParams struct {
+	Description        sql.NullString
+	AmazonAssociatesID sql.NullString
+	Facebook           sql.NullString
+	Twitter            sql.NullString
+	Linkedin           sql.NullString
+	Instagram          sql.NullString
+	Phone              sql.NullString
+	Whatsapp           sql.NullString
+	Telegram           sql.NullString
+	ID                 int64
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.ExecContext(ctx, updateUser,
+		arg.Description,
+		arg.AmazonAssociatesID,
+		arg.Facebook,
+		arg.Twitter,
+		arg.Linkedin,
+		arg.Instagram,
+		arg.Phone,
+		arg.Whatsapp,
+		arg.Telegram,
+		arg.ID,
+	)
+	return err
+}
+
+const updateWish = `-- name: UpdateWish :exec
+UPDATE wishes
+   SET isbn = $1,
+       title = $2,
+       subtitle = $3,
+       author = $4,
+       description = $5,
+       publisher = $6,
+       page_count = $7,
+       google_books_id = $8,
+       series_name = $9,
+       series_position = $10,
+       priority = $11,
+       note = $12,
+       source = $13,
+       updated_at = CURRENT_TIMESTAMP
+ WHERE id = $14
+`
+
+type UpdateWishParams struct {
+	Isbn           string
+	Title          string
+	Subtitle       string
+	Author         string
+	Description    string
+	Publisher      string
+	PageCount      int32
+	GoogleBooksID  sql.NullString
+	SeriesName     string
+	SeriesPosition sql.NullInt32
+	Priority       int16
+	Note           string
+	Source         string
+	ID             int64
+}
+
+func (q *Queries) UpdateWish(ctx context.Context, arg UpdateWishParams) error {
+	_, err := q.db.ExecContext(ctx, updateWish,
+		arg.Isbn,
+		arg.Title,
+		arg.Subtitle,
+		arg.Author,
+		arg.Description,
+		arg.Publisher,
+		arg.PageCount,
+		arg.GoogleBooksID,
+		arg.SeriesName,
+		arg.SeriesPosition,
+		arg.Priority,
+		arg.Note,
+		arg.Source,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertAuthor = `-- name: UpsertAuthor :one
+INSERT INTO authors (user_id, name)
+VALUES ($1, $2)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET updated_at = authors.updated_at
+       RETURNING id, user_id, name, created_at, updated_at
+`
+
+type UpsertAuthorParams struct {
+	UserID int64
+	Name   string
+}
+
+func (q *Queries) UpsertAuthor(ctx context.Context, arg UpsertAuthorParams) (Author, error) {
+	row := q.db.QueryRowContext(ctx, upsertAuthor, arg.UserID, arg.Name)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertSeries = `-- name: UpsertSeries :one
+INSERT INTO series (user_id, name, google_series_id)
+VALUES ($1, $2, $3)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET google_series_id = coalesce(EXCLUDED.google_series_id, series.google_series_id)
+       RETURNING id, user_id, name, volumes, google_series_id, created_at, updated_at
+`
+
+type UpsertSeriesParams struct {
+	UserID         int64
+	Name           string
+	GoogleSeriesID sql.NullString
+}
+
+func (q *Queries) UpsertSeries(ctx context.Context, arg UpsertSeriesParams) (Series, error) {
+	row := q.db.QueryRowContext(ctx, upsertSeries, arg.UserID, arg.Name, arg.GoogleSeriesID)
+	var i Series
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Volumes,
+		&i.GoogleSeriesID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+       ON CONFLICT (user_id, name)
+       DO UPDATE SET updated_at = tags.updated_at
+       RETURNING id, user_id, name, created_at, updated_at
+`
+
+type UpsertTagParams struct {
+	UserID int64
+	Name   string
+}
+
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, upsertTag, arg.UserID, arg.Name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const user = `-- name: User :one
+SELECT id, name, email, image, created_at, updated_at, slug, description, facebook, twitter, linkedin, instagram, phone, whatsapp, telegram, amazon_associates_id, admin FROM users WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) User(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, user, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Slug,
+		&i.Description,
+		&i.Facebook,
+		&i.Twitter,
+		&i.Linkedin,
+		&i.Instagram,
+		&i.Phone,
+		&i.Whatsapp,
+		&i.Telegram,
+		&i.AmazonAssociatesID,
+		&i.Admin,
+	)
+	return i, err
+}
+
+const userBooks = `-- name: UserBooks :many
+SELECT id, title, author, image, isbn, created_at, updated_at, user_id, google_books_id, subtitle, description, page_count, publisher, page_read, work_id, rating, review, deleted_at FROM books WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at, id
+`
+
+func (q *Queries) UserBooks(ctx context.Context, userID int64) ([]Book, error) {
+	rows, err := q.db.QueryContext(ctx, userBooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Book
+	for rows.Next() {
+		var i Book
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Author,
+			&i.Image,
+			&i.Isbn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.GoogleBooksID,
+			&i.Subtitle,
+			&i.Description,
+			&i.PageCount,
+			&i.Publisher,
+			&i.PageRead,
+			&i.WorkID,
+			&i.Rating,
+			&i.Review,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const userBulkAdds = `-- name: UserBulkAdds :many
+SELECT id, user_id, shelf_id, status, created_at, updated_at FROM bulk_adds WHERE user_id = $1 ORDER BY created_at DESC LIMIT 5
+`
+
+func (q *Queries) UserBulkAdds(ctx context.Context, userID int64) ([]BulkAdd, error) {
+	rows, err := q.db.QueryContext(ctx, userBulkAdds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BulkAdd
+	for rows.Next() {
+		var i BulkAdd
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ShelfID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const userBySlug = `-- name: UserBySlug :one
+SELECT id, name, email, image, created_at, updated_at, slug, description, facebook, twitter, linkedin, instagram, phone, whatsapp, telegram, amazon_associates_id, admin FROM users WHERE slug = $1 LIMIT 1
+`
+
+func (q *Queries) UserBySlug(ctx context.Context, slug string) (User, error) {
+	row := q.db.QueryRowContext(ctx, userBySlug, slug)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Slug,
+		&i.Description,
+		&i.Facebook,
+		&i.Twitter,
+		&i.Linkedin,
+		&i.Instagram,
+		&i.Phone,
+		&i.Whatsapp,
+		&i.Telegram,
+		&i.AmazonAssociatesID,
+		&i.Admin,
+	)
+	return i, err
+}
+
+const userMetadataSuggestions = `-- name: UserMetadataSuggestions :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count
+  FROM metadata_suggestions, books, users
+ WHERE books.id = metadata_suggestions.book_id
+   AND users.id = books.user_id
+   AND books.user_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY books.id
+`
+
+type UserMetadataSuggestionsRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageRead      int32
+	PageCount     int32
+}
+
+func (q *Queries) UserMetadataSuggestions(ctx context.Context, userID int64) ([]UserMetadataSuggestionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, userMetadataSuggestions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserMetadataSuggestionsRow
+	for rows.Next() {
+		var i UserMetadataSuggestionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageRead,
+			&i.PageCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const userSeries = `-- name: UserSeries :many
+SELECT series.id, series.user_id, series.name, series.volumes, series.google_series_id, series.created_at, series.updated_at, count(works.id) works_count
+  FROM series
+       LEFT JOIN works
+           ON works.series_id = series.id
+          AND EXISTS (SELECT 1 FROM books WHERE books.work_id = works.id AND books.deleted_at IS NULL)
+ WHERE series.user_id = $1
+ GROUP BY series.id
+HAVING count(works.id) > 0
+ ORDER BY name
+`
+
+type UserSeriesRow struct {
+	ID             int64
+	UserID         int64
+	Name           string
+	Volumes        sql.NullInt32
+	GoogleSeriesID sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	WorksCount     int64
+}
+
+func (q *Queries) UserSeries(ctx context.Context, userID int64) ([]UserSeriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, userSeries, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSeriesRow
+	for rows.Next() {
+		var i UserSeriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Volumes,
+			&i.GoogleSeriesID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorksCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const userTags = `-- name: UserTags :many
+SELECT tags.id, tags.user_id, tags.name, tags.created_at, tags.updated_at, count(book_tags.book_id) books_count
+  FROM tags, book_tags, books
+ WHERE book_tags.tag_id = tags.id
+   AND books.id = book_tags.book_id
+   AND tags.user_id = $1
+   AND books.deleted_at IS NULL
+ GROUP BY tags.id
+ ORDER BY name
+`
+
+type UserTagsRow struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	BooksCount int64
+}
+
+func (q *Queries) UserTags(ctx context.Context, userID int64) ([]UserTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, userTags, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserTagsRow
+	for rows.Next() {
+		var i UserTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.BooksCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const updateShelf = `-- name: UpdateShelf :exec
-UPDATE shelves SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+const userUnshelvedBooks = `-- name: UserUnshelvedBooks :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_count, page_read, copies.id copy_id, rating
+  FROM copies, books, users
+ WHERE books.id = copies.book_id
+   AND users.id = books.user_id
+   AND user_id = $1
+   AND books.deleted_at IS NULL
+   AND NOT EXISTS (SELECT 1 FROM shelves WHERE shelves.id = copies.shelf_id AND shelves.deleted_at IS NULL)
+ ORDER BY CASE WHEN $2::boolean THEN rating END DESC NULLS LAST, copies.created_at DESC
 `
 
-type UpdateShelfParams struct {
-	Name string
-	ID   int64
+type UserUnshelvedBooksParams struct {
+	UserID   int64
+	TopRated bool
 }
 
-func (q *Queries) UpdateShelf(ctx context.Context, arg UpdateShelfParams) error {
-	_, err := q.db.ExecContext(ctx, updateShelf, arg.Name, arg.ID)
-	return err
+type UserUnshelvedBooksRow struct {
+	ID            int64
+	Title         string
+	Image         sql.NullString
+	GoogleBooksID sql.NullString
+	Slug          string
+	Isbn          string
+	PageCount     int32
+	PageRead      int32
+	CopyID        int64
+	Rating        sql.NullInt16
 }
 
-const updateUser = `-- name: UpdateUser :exec
-UPDATE users
-   SET description = $1,
-       amazon_associates_id = $2,
-       facebook = $3,
-       twitter = $4,
-       linkedin = $5,
-       instagram = $6,
-       phone = $7,
-       whatsapp = $8,
-       telegram = $9,
-       updated_at = CURRENT_TIMESTAMP
- WHERE id = $10
+func (q *Queries) UserUnshelvedBooks(ctx context.Context, arg UserUnshelvedBooksParams) ([]UserUnshelvedBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, userUnshelvedBooks, arg.UserID, arg.TopRated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserUnshelvedBooksRow
+	for rows.Next() {
+		var i UserUnshelvedBooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Image,
+			&i.GoogleBooksID,
+			&i.Slug,
+			&i.Isbn,
+			&i.PageCount,
+			&i.PageRead,
+			&i.CopyID,
+			&i.Rating,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const userWishes = `-- name: UserWishes :many
+SELECT id, user_id, isbn, title, subtitle, author, description, publisher, page_count, google_books_id, series_name, series_position, priority, note, source, reserved_by, reserved_at, created_at, updated_at, reserver_id, reserver_token FROM wishes WHERE user_id = $1 ORDER BY priority DESC, created_at DESC
 `
 
-type UpdateUserParams struct {
+func (q *Queries) UserWishes(ctx context.Context, userID int64) ([]Wish, error) {
+	rows, err := q.db.QueryContext(ctx, userWishes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Wish
+	for rows.Next() {
+		var i Wish
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Isbn,
+			&i.Title,
+			&i.Subtitle,
+			&i.Author,
+			&i.Description,
+			&i.Publisher,
+			&i.PageCount,
+			&i.GoogleBooksID,
+			&i.SeriesName,
+			&i.SeriesPosition,
+			&i.Priority,
+			&i.Note,
+			&i.Source,
+			&i.ReservedBy,
+			&i.ReservedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ReserverID,
+			&i.ReserverToken,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const users = `-- name: Users :many
+SELECT users.id, users.name, users.email, users.image, users.created_at, users.updated_at, users.slug, users.description, users.facebook, users.twitter, users.linkedin, users.instagram, users.phone, users.whatsapp, users.telegram, users.amazon_associates_id, users.admin, (SELECT count(*) FROM books WHERE books.user_id = users.id AND books.deleted_at IS NULL) books_count
+  FROM users
+ ORDER BY users.id
+`
+
+type UsersRow struct {
+	ID                 int64
+	Name               sql.NullString
+	Email              sql.NullString
+	Image              sql.NullString
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Slug               string
 	Description        sql.NullString
-	AmazonAssociatesID sql.NullString
 	Facebook           sql.NullString
 	Twitter            sql.NullString
 	Linkedin           sql.NullString
@@ -621,109 +3525,121 @@ type UpdateUserParams struct {
 	Phone              sql.NullString
 	Whatsapp           sql.NullString
 	Telegram           sql.NullString
-	ID                 int64
+	AmazonAssociatesID sql.NullString
+	Admin              bool
+	BooksCount         int64
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.ExecContext(ctx, updateUser,
-		arg.Description,
-		arg.AmazonAssociatesID,
-		arg.Facebook,
-		arg.Twitter,
-		arg.Linkedin,
-		arg.Instagram,
-		arg.Phone,
-		arg.Whatsapp,
-		arg.Telegram,
-		arg.ID,
-	)
-	return err
+func (q *Queries) Users(ctx context.Context) ([]UsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UsersRow
+	for rows.Next() {
+		var i UsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Slug,
+			&i.Description,
+			&i.Facebook,
+			&i.Twitter,
+			&i.Linkedin,
+			&i.Instagram,
+			&i.Phone,
+			&i.Whatsapp,
+			&i.Telegram,
+			&i.AmazonAssociatesID,
+			&i.Admin,
+			&i.BooksCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const user = `-- name: User :one
-SELECT id, name, email, image, created_at, updated_at, slug, description, facebook, twitter, linkedin, instagram, phone, whatsapp, telegram, amazon_associates_id FROM users WHERE id = $1 LIMIT 1
+const wishByIDAndUser = `-- name: WishByIDAndUser :one
+SELECT id, user_id, isbn, title, subtitle, author, description, publisher, page_count, google_books_id, series_name, series_position, priority, note, source, reserved_by, reserved_at, created_at, updated_at, reserver_id, reserver_token FROM wishes WHERE id = $1 AND user_id = $2 LIMIT 1
 `
 
-func (q *Queries) User(ctx context.Context, id int64) (User, error) {
-	row := q.db.QueryRowContext(ctx, user, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.Image,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Slug,
-		&i.Description,
-		&i.Facebook,
-		&i.Twitter,
-		&i.Linkedin,
-		&i.Instagram,
-		&i.Phone,
-		&i.Whatsapp,
-		&i.Telegram,
-		&i.AmazonAssociatesID,
-	)
-	return i, err
+type WishByIDAndUserParams struct {
+	ID     int64
+	UserID int64
 }
 
-const userBySlug = `-- name: UserBySlug :one
-SELECT id, name, email, image, created_at, updated_at, slug, description, facebook, twitter, linkedin, instagram, phone, whatsapp, telegram, amazon_associates_id FROM users WHERE slug = $1 LIMIT 1
-`
-
-func (q *Queries) UserBySlug(ctx context.Context, slug string) (User, error) {
-	row := q.db.QueryRowContext(ctx, userBySlug, slug)
-	var i User
+func (q *Queries) WishByIDAndUser(ctx context.Context, arg WishByIDAndUserParams) (Wish, error) {
+	row := q.db.QueryRowContext(ctx, wishByIDAndUser, arg.ID, arg.UserID)
+	var i Wish
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.Image,
+		&i.UserID,
+		&i.Isbn,
+		&i.Title,
+		&i.Subtitle,
+		&i.Author,
+		&i.Description,
+		&i.Publisher,
+		&i.PageCount,
+		&i.GoogleBooksID,
+		&i.SeriesName,
+		&i.SeriesPosition,
+		&i.Priority,
+		&i.Note,
+		&i.Source,
+		&i.ReservedBy,
+		&i.ReservedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Slug,
-		&i.Description,
-		&i.Facebook,
-		&i.Twitter,
-		&i.Linkedin,
-		&i.Instagram,
-		&i.Phone,
-		&i.Whatsapp,
-		&i.Telegram,
-		&i.AmazonAssociatesID,
+		&i.ReserverID,
+		&i.ReserverToken,
 	)
 	return i, err
 }
 
-const userUnshelvedBooks = `-- name: UserUnshelvedBooks :many
-SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_count, page_read
+const workEditions = `-- name: WorkEditions :many
+SELECT books.id id, title, books.image image, google_books_id, slug, isbn, page_read, page_count, publisher
   FROM books, users
  WHERE users.id = books.user_id
-   AND user_id = $1
-   AND shelf_id IS NULL
+   AND work_id = $1
+   AND books.deleted_at IS NULL
+ ORDER BY books.created_at
 `
 
-type UserUnshelvedBooksRow struct {
+type WorkEditionsRow struct {
 	ID            int64
 	Title         string
 	Image         sql.NullString
 	GoogleBooksID sql.NullString
 	Slug          string
 	Isbn          string
-	PageCount     int32
 	PageRead      int32
+	PageCount     int32
+	Publisher     string
 }
 
-func (q *Queries) UserUnshelvedBooks(ctx context.Context, userID int64) ([]UserUnshelvedBooksRow, error) {
-	rows, err := q.db.QueryContext(ctx, userUnshelvedBooks, userID)
+func (q *Queries) WorkEditions(ctx context.Context, workID int64) ([]WorkEditionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, workEditions, workID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []UserUnshelvedBooksRow
+	var items []WorkEditionsRow
 	for rows.Next() {
-		var i UserUnshelvedBooksRow
+		var i WorkEditionsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
@@ -731,8 +3647,64 @@ func (q *Queries) UserUnshelvedBooks(ctx context.Context, userID int64) ([]UserU
 			&i.GoogleBooksID,
 			&i.Slug,
 			&i.Isbn,
-			&i.PageCount,
 			&i.PageRead,
+			&i.PageCount,
+			&i.Publisher,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const workHighlights = `-- name: WorkHighlights :many
+SELECT highlights.id, highlights.book_id, highlights.page, highlights.content, highlights.image, highlights.created_at, highlights.updated_at, highlights.deleted_at, isbn
+  FROM highlights, books
+ WHERE books.id = highlights.book_id
+   AND work_id = $1
+   AND books.deleted_at IS NULL
+   AND highlights.deleted_at IS NULL
+ ORDER BY page
+`
+
+type WorkHighlightsRow struct {
+	ID        int64
+	BookID    int64
+	Page      int32
+	Content   string
+	Image     sql.NullString
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt sql.NullTime
+	Isbn      string
+}
+
+func (q *Queries) WorkHighlights(ctx context.Context, workID int64) ([]WorkHighlightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, workHighlights, workID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkHighlightsRow
+	for rows.Next() {
+		var i WorkHighlightsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.Page,
+			&i.Content,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Isbn,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// Ratings are stored in half stars, 1 is half a star and MAX_RATING is five
+// stars
+const MAX_RATING = 10
+
+type RatingOption struct {
+	Value int16
+	Label string
+}
+
+// RatingOptions lists ratings from the highest down for the review form select
+func RatingOptions() []RatingOption {
+	options := make([]RatingOption, 0, MAX_RATING)
+	for i := MAX_RATING; i > 0; i-- {
+		options = append(options, RatingOption{
+			Value: int16(i),
+			Label: strings.TrimSuffix(fmt.Sprintf("%.1f", float64(i)/2), ".0") + " ★",
+		})
+	}
+
+	return options
+}
+
+// ParseRating converts the form value to a rating, empty value clears it
+func ParseRating(s string) sql.NullInt16 {
+	i, err := strconv.ParseInt(s, 10, 16)
+	if err != nil {
+		return sql.NullInt16{}
+	}
+
+	return sql.NullInt16{Int16: int16(i), Valid: true}
+}
+
+// Stars renders a rating as full and half star icons
+func Stars(rating int16) template.HTML {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf(`<span class="has-text-warning" title="%.1f/5">`, float64(rating)/2))
+	for i := int16(2); i <= MAX_RATING; i += 2 {
+		switch {
+		case rating >= i:
+			out.WriteString(`<i class="fa-solid fa-star"></i>`)
+		case rating == i-1:
+			out.WriteString(`<i class="fa-solid fa-star-half-stroke"></i>`)
+		default:
+			out.WriteString(`<i class="fa-regular fa-star"></i>`)
+		}
+	}
+	out.WriteString(`</span>`)
+
+	return template.HTML(out.String())
+}
+
+type RatingSummary struct {
+	Count int64
+	Sum   int64
+}
+
+// Average is the average rating in half stars rounded to the nearest half
+func (r RatingSummary) Average() int16 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	return int16((r.Sum*2 + r.Count) / (r.Count * 2))
+}
+
+// ShelfRating aggregates ratings of the rated books of a shelf
+func ShelfRating(books []ShelfBooksRow) RatingSummary {
+	r := RatingSummary{}
+	for _, b := range books {
+		if b.Rating.Valid {
+			r.Count++
+			r.Sum += int64(b.Rating.Int16)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// routerTemplates makes error pages render their title only
+func routerTemplates() {
+	templates = template.Must(template.New("layout").Parse(`{{ .title }}`))
+}
+
+// echoRoute responds with the route name and its path values
+func echoRoute(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := VARS(r)
+		keys := []string{}
+		for k := range vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := name
+		for _, k := range keys {
+			out += fmt.Sprintf(" %s=%s", k, vars[k])
+		}
+		fmt.Fprint(w, out)
+	}
+}
+
+// staticRoute adds the static files route like Start does
+func staticRoute(h *Handler, dir string) {
+	h.checks = append(h.checks, &Route{
+		method: http.MethodGet,
+		name:   "static",
+		check:  checkStaticFile(http.Dir(dir)),
+		route:  echoRoute("static"),
+		index:  h.count,
+	})
+	h.count++
+}
+
+func TestRouter(t *testing.T) {
+	routerTemplates()
+
+	static := t.TempDir()
+	if err := os.WriteFile(path.Join(static, "style.css"), []byte("body {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path.Join(static, "books"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{}
+	h.add(http.MethodGet, "/", echoRoute("root"))
+	h.add(http.MethodGet, "/users/new", echoRoute("new_user"))
+	h.add(http.MethodGet, "/users/{user}", echoRoute("user"))
+	h.add(http.MethodPut, "/users/{user}", echoRoute("update_user"))
+	h.add(http.MethodGet, "/users/{user}/books/{isbn}", echoRoute("book"))
+	h.add(http.MethodGet, "/users/{user}/books/{isbn}/edit", echoRoute("edit_book"))
+	h.add(http.MethodGet, "/{user}/shelf", echoRoute("param_first"))
+	h.add(http.MethodGet, "/books/shelf", echoRoute("literal_second"))
+	h.add(http.MethodPost, "/books", echoRoute("create_book"))
+	h.add(http.MethodGet, "/books/{isbn}", echoRoute("show_book"))
+	staticRoute(h, static)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{"root", "GET", "/", 200, "root", ""},
+		{"literal before param", "GET", "/users/new", 200, "new_user", ""},
+		{"param", "GET", "/users/emad", 200, "user user=emad", ""},
+		{"first registered wins", "GET", "/books/shelf", 200, "param_first user=books", ""},
+		{"literal when the param route doesn't match", "GET", "/books/9780441013593", 200, "show_book isbn=9780441013593", ""},
+		{"deep params", "GET", "/users/emad/books/9780441013593/edit", 200, "edit_book isbn=9780441013593 user=emad", ""},
+		{"escaped slash in a value", "GET", "/users/a%2Fb", 200, "user user=a/b", ""},
+		{"head with get routes", "HEAD", "/users/emad", 200, "user user=emad", ""},
+		{"other method", "PUT", "/users/emad", 200, "update_user user=emad", ""},
+		{"method mismatch", "DELETE", "/users/emad", 405, "Method Not Allowed", "GET, HEAD, PUT"},
+		{"method mismatch on post only route", "GET", "/books", 405, "Method Not Allowed", "POST"},
+		{"method mismatch on static file", "POST", "/style.css", 405, "Method Not Allowed", "GET, HEAD"},
+		{"trailing slash", "GET", "/users/emad/", 404, "Not Found", ""},
+		{"trailing slash on a literal", "GET", "/users/new/", 404, "Not Found", ""},
+		{"empty param", "GET", "/users//books/9780441013593", 404, "Not Found", ""},
+		{"static file", "GET", "/style.css", 200, "static", ""},
+		{"static directory", "GET", "/books/", 404, "Not Found", ""},
+		{"missing static file", "GET", "/missing.css", 404, "Not Found", ""},
+		{"too deep", "GET", "/users/emad/books/9780441013593/edit/more", 404, "Not Found", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+
+			if body := strings.TrimSpace(w.Body.String()); tc.method != "HEAD" && body != tc.body {
+				t.Errorf("expected body %q, got %q", tc.body, body)
+			}
+
+			if allow := w.Header().Get("Allow"); allow != tc.allow {
+				t.Errorf("expected Allow %q, got %q", tc.allow, allow)
+			}
+		})
+	}
+}
+
+// benchmarkRouter is a route table shaped like the application routes
+func benchmarkRouter(b *testing.B) *Handler {
+	routerTemplates()
+
+	h := &Handler{}
+	h.add(http.MethodGet, "/", echoRoute("root"))
+	h.add(http.MethodGet, "/login", echoRoute("login"))
+	h.add(http.MethodGet, "/logout", echoRoute("logout"))
+	h.add(http.MethodGet, "/search", echoRoute("search"))
+
+	resources := []string{"books", "shelves", "highlights", "notes", "wishlist", "authors", "series", "tags", "publishers", "goals"}
+	for _, res := range resources {
+		base := "/users/{user}/" + res
+		h.add(http.MethodGet, base, echoRoute(res))
+		h.add(http.MethodGet, base+"/new", echoRoute("new_"+res))
+		h.add(http.MethodPost, base, echoRoute("create_"+res))
+		h.add(http.MethodGet, base+"/{id}", echoRoute("show_"+res))
+		h.add(http.MethodGet, base+"/{id}/edit", echoRoute("edit_"+res))
+		h.add(http.MethodPut, base+"/{id}", echoRoute("update_"+res))
+		h.add(http.MethodDelete, base+"/{id}", echoRoute("delete_"+res))
+	}
+
+	for _, res := range []string{"highlights", "notes", "copies", "history"} {
+		base := "/users/{user}/books/{isbn}/" + res
+		h.add(http.MethodGet, base, echoRoute(res))
+		h.add(http.MethodPost, base, echoRoute("create_"+res))
+		h.add(http.MethodGet, base+"/{id}/edit", echoRoute("edit_"+res))
+		h.add(http.MethodPut, base+"/{id}", echoRoute("update_"+res))
+	}
+
+	staticRoute(h, b.TempDir())
+	return h
+}
+
+func benchmarkRoute(b *testing.B, method, path string, status int) {
+	h := benchmarkRouter(b)
+	r := httptest.NewRequest(method, path, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != status {
+		b.Fatalf("expected status %d, got %d", status, w.Code)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	benchmarkRoute(b, http.MethodGet, "/login", http.StatusOK)
+}
+
+func BenchmarkRouterDeepParam(b *testing.B) {
+	benchmarkRoute(b, http.MethodGet, "/users/emad/books/9780441013593/highlights/12/edit", http.StatusOK)
+}
+
+func BenchmarkRouterMiss(b *testing.B) {
+	benchmarkRoute(b, http.MethodGet, "/users/emad/nothing/here", http.StatusNotFound)
+}
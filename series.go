@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// MAX_SERIES_VOLUMES caps series volumes and positions, the series page lists
+// every volume up to the last one
+const MAX_SERIES_VOLUMES = 1000
+
+// SeriesForm holds the series fields of the book form, they belong to the work
+// not the edition
+type SeriesForm struct {
+	Name           string
+	Position       sql.NullInt32
+	GoogleSeriesID string
+}
+
+func SeriesFormFromRequest(r Request) SeriesForm {
+	return SeriesForm{
+		Name:           strings.TrimSpace(r.FormValue("series")),
+		Position:       NullInt32(r.FormValue("series_position")),
+		GoogleSeriesID: r.FormValue("google_series_id"),
+	}
+}
+
+func (s SeriesForm) Validate(ve ValidationErrors) {
+	ValidateStringLength(s.Name, "series", "Series", ve, 0, 100)
+	ValidateStringLength(s.GoogleSeriesID, "google_series_id", "Google Series ID", ve, 0, 30)
+	if s.Position.Valid {
+		ValidateInt32Min(s.Position.Int32, "series_position", "Number in series", ve, 0)
+		ValidateInt32Max(s.Position.Int32, "series_position", "Number in series", ve, MAX_SERIES_VOLUMES)
+	}
+}
+
+// SetWorkSeries puts the work in the series with the form name creating it if
+// needed, an empty name removes the work from its series
+func SetWorkSeries(ctx context.Context, q *Queries, userID, workID int64, s SeriesForm) error {
+	params := SetWorkSeriesParams{ID: workID}
+
+	if len(s.Name) > 0 {
+		series, err := q.UpsertSeries(ctx, UpsertSeriesParams{
+			UserID:         userID,
+			Name:           s.Name,
+			GoogleSeriesID: NullString(s.GoogleSeriesID),
+		})
+		if err != nil {
+			return err
+		}
+
+		params.SeriesID = sql.NullInt64{Int64: series.ID, Valid: true}
+		params.SeriesPosition = s.Position
+	}
+
+	if err := q.SetWorkSeries(ctx, params); err != nil {
+		return err
+	}
+
+	return q.DeleteOrphanSeries(ctx, userID)
+}
+
+type SeriesVolume struct {
+	Position int32
+	Books    []SeriesBooksRow
+}
+
+// SeriesVolumes lays out the series books by their position from 1 to the last
+// known volume, positions without books are the missing volumes. books without a
+// position or with one over MAX_SERIES_VOLUMES are returned separately.
+func SeriesVolumes(books []SeriesBooksRow, count sql.NullInt32) (volumes []SeriesVolume, unnumbered []SeriesBooksRow) {
+	last := count.Int32
+	for _, b := range books {
+		if b.SeriesPosition.Int32 > last {
+			last = b.SeriesPosition.Int32
+		}
+	}
+	if last > MAX_SERIES_VOLUMES {
+		last = MAX_SERIES_VOLUMES
+	}
+	if last < 0 {
+		last = 0
+	}
+
+	volumes = make([]SeriesVolume, last)
+	for i := range volumes {
+		volumes[i].Position = int32(i + 1)
+	}
+
+	for _, b := range books {
+		if !b.SeriesPosition.Valid || b.SeriesPosition.Int32 < 1 || b.SeriesPosition.Int32 > last {
+			unnumbered = append(unnumbered, b)
+			continue
+		}
+
+		v := &volumes[b.SeriesPosition.Int32-1]
+		v.Books = append(v.Books, b)
+	}
+
+	return
+}
+
+// SeriesNeighbours finds the books before and after the work in series order
+func SeriesNeighbours(books []SeriesBooksRow, workID int64) (prev, next *SeriesBooksRow) {
+	for i, b := range books {
+		if b.WorkID != workID {
+			continue
+		}
+
+		for j := i - 1; j >= 0 && prev == nil; j-- {
+			if books[j].WorkID != workID {
+				prev = &books[j]
+			}
+		}
+
+		for j := i + 1; j < len(books) && next == nil; j++ {
+			if books[j].WorkID != workID {
+				next = &books[j]
+			}
+		}
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// ParseTags splits a comma separated list of tags, tags are lower cased with
+// spaces collapsed. slashes are replaced as tags are part of the tag page path
+func ParseTags(s string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		t = strings.ReplaceAll(t, "/", "-")
+		if len(t) == 0 || seen[t] {
+			continue
+		}
+
+		seen[t] = true
+		tags = append(tags, t)
+	}
+
+	return tags
+}
+
+func TagsString(tags []Tag) string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// SetBookTags replaces the book tags with the ones in tags string
+func SetBookTags(ctx context.Context, q *Queries, userID, bookID int64, tags string) error {
+	if err := q.DeleteBookTags(ctx, bookID); err != nil {
+		return err
+	}
+
+	if err := AddBookTags(ctx, q, userID, bookID, tags); err != nil {
+		return err
+	}
+
+	return q.DeleteOrphanTags(ctx, userID)
+}
+
+// AddBookTags adds the tags in tags string to the book keeping its current tags
+func AddBookTags(ctx context.Context, q *Queries, userID, bookID int64, tags string) error {
+	for _, name := range ParseTags(tags) {
+		tag, err := q.UpsertTag(ctx, UpsertTagParams{
+			UserID: userID,
+			Name:   name,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.NewBookTag(ctx, NewBookTagParams{
+			BookID: bookID,
+			TagID:  tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	TRASH_RETENTION_DAYS = 30
+	TRASH_PURGE_INTERVAL = time.Hour
+)
+
+// TrashRetention is how long deleted books, highlights and shelves stay in the
+// trash, it can be changed with TRASH_RETENTION_DAYS environment variable
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = TRASH_RETENTION_DAYS
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PurgeTrash permanently deletes what stayed in the trash longer than the
+// retention period then repeats every TRASH_PURGE_INTERVAL
+func PurgeTrash() {
+	for {
+		if err := purgeTrash(context.Background(), time.Now().Add(-TrashRetention())); err != nil {
+			log.Printf("Purging trash failed: %s", err)
+		}
+
+		time.Sleep(TRASH_PURGE_INTERVAL)
+	}
+}
+
+func purgeTrash(ctx context.Context, before time.Time) error {
+	deletedAt := sql.NullTime{Time: before, Valid: true}
+	images := []string{}
+
+	err := Transaction(ctx, func(q *Queries) error {
+		covers, err := q.ExpiredBookImages(ctx, deletedAt)
+		if err != nil {
+			return err
+		}
+		for _, v := range covers {
+			images = append(images, path.Join(BOOK_COVER_PATH, v.String))
+		}
+
+		highlights, err := q.ExpiredHighlightImages(ctx, deletedAt)
+		if err != nil {
+			return err
+		}
+		for _, v := range highlights {
+			images = append(images, path.Join(HIGHLIGHT_IMAGE_PATH, v.String))
+		}
+
+		if err = q.PurgeHighlights(ctx, deletedAt); err != nil {
+			return err
+		}
+
+		if err = q.PurgeShelves(ctx, deletedAt); err != nil {
+			return err
+		}
+
+		users, err := q.PurgeBooks(ctx, deletedAt)
+		if err != nil {
+			return err
+		}
+
+		purged := map[int64]bool{}
+		for _, userID := range users {
+			if purged[userID] {
+				continue
+			}
+			purged[userID] = true
+
+			if err = DeleteOrphans(ctx, q, userID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// files are removed after the rows are gone so a failed purge doesn't
+	// leave books without images
+	for _, p := range images {
+		os.Remove(p)
+	}
+
+	return nil
+}
+
+// DeleteOrphans deletes the user works, series, tags and authors that are left
+// without books
+func DeleteOrphans(ctx context.Context, q *Queries, userID int64) error {
+	if err := q.DeleteOrphanWorks(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := q.DeleteOrphanSeries(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := q.DeleteOrphanTags(ctx, userID); err != nil {
+		return err
+	}
+
+	return q.DeleteOrphanAuthors(ctx, userID)
+}
+
+// TrashLocals loads what the user has in the trash for the trash page
+func TrashLocals(ctx context.Context, userID int64) (Locals, error) {
+	books, err := Q.TrashedBooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	highlights, err := Q.TrashedHighlights(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shelves, err := Q.TrashedShelves(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return Locals{
+		"books":          books,
+		"highlights":     highlights,
+		"shelves":        shelves,
+		"retention_days": int(TrashRetention().Hours() / 24),
+	}, nil
+}
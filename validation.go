@@ -1,5 +1,10 @@
 package main
 
+import (
+	"fmt"
+	"strings"
+)
+
 func (n NewBookParams) Validate() ValidationErrors {
 	ve := ValidationErrors{}
 	ValidateStringPresent(n.Title, "title", "Title", ve)
@@ -8,7 +13,8 @@ func (n NewBookParams) Validate() ValidationErrors {
 	ValidateStringLength(n.Subtitle, "subtitle", "Subtitle", ve, 0, 100)
 
 	ValidateStringPresent(n.Author, "author", "Author", ve)
-	ValidateStringLength(n.Author, "author", "Author", ve, 0, 100)
+	ValidateStringLength(n.Author, "author", "Author", ve, 0, 500)
+	ValidateAuthors(n.Author, "author", "Author", ve)
 
 	ValidateStringNumeric(n.Isbn, "isbn", "ISBN", ve)
 	ValidateISBN13(n.Isbn, "isbn", "ISBN", ve)
@@ -28,7 +34,8 @@ func (n UpdateBookParams) Validate() ValidationErrors {
 	ValidateStringLength(n.Subtitle, "subtitle", "Subtitle", ve, 0, 100)
 
 	ValidateStringPresent(n.Author, "author", "Author", ve)
-	ValidateStringLength(n.Author, "author", "Author", ve, 0, 100)
+	ValidateStringLength(n.Author, "author", "Author", ve, 0, 500)
+	ValidateAuthors(n.Author, "author", "Author", ve)
 
 	ValidateStringLength(n.Description, "description", "Description", ve, 0, 5000)
 	ValidateStringLength(n.Publisher, "publisher", "Publisher", ve, 0, 50)
@@ -76,3 +83,149 @@ func (n UpdateShelfParams) Validate() ValidationErrors {
 	ValidateStringLength(n.Name, "name", "Name", ve, 3, 100)
 	return ve
 }
+
+func (n UpdateCopyParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateStringLength(n.Condition, "condition", "Condition", ve, 0, 50)
+	ValidateStringLength(n.LentTo.String, "lent_to", "Lent to", ve, 0, 100)
+	return ve
+}
+
+func (n UpdateAuthorParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateStringPresent(n.Name, "name", "Name", ve)
+	ValidateStringLength(n.Name, "name", "Name", ve, 0, 100)
+	return ve
+}
+
+func ValidateAuthors(val, key, label string, ve ValidationErrors) {
+	for _, a := range ParseAuthors(val) {
+		ValidateStringLength(a.Name, key, label, ve, 0, 100)
+
+		valid := false
+		for _, r := range AUTHOR_ROLES {
+			valid = valid || r == a.Role
+		}
+		if !valid {
+			ve.Add(key, fmt.Errorf("%s role %s has to be one of %s", label, a.Role, strings.Join(AUTHOR_ROLES, ", ")))
+		}
+	}
+}
+
+func (n UpdateSeriesParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateStringPresent(n.Name, "name", "Name", ve)
+	ValidateStringLength(n.Name, "name", "Name", ve, 0, 100)
+	if n.Volumes.Valid {
+		ValidateInt32Min(n.Volumes.Int32, "volumes", "Volumes", ve, 1)
+		ValidateInt32Max(n.Volumes.Int32, "volumes", "Volumes", ve, MAX_SERIES_VOLUMES)
+	}
+	return ve
+}
+
+func ValidateTags(val, key, label string, ve ValidationErrors) {
+	for _, t := range ParseTags(val) {
+		ValidateStringLength(t, key, label, ve, 0, 30)
+	}
+}
+
+func (u UpdateBookReviewParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	if u.Rating.Valid && (u.Rating.Int16 < 1 || u.Rating.Int16 > MAX_RATING) {
+		ve.Add("rating", fmt.Errorf("Rating has to be between half a star and five stars"))
+	}
+
+	ValidateStringLength(u.Review, "review", "Review", ve, 0, 10000)
+
+	return ve
+}
+
+func (n NewWishParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateWish(UpdateWishParams{
+		Isbn:           n.Isbn,
+		Title:          n.Title,
+		Subtitle:       n.Subtitle,
+		Author:         n.Author,
+		Description:    n.Description,
+		Publisher:      n.Publisher,
+		PageCount:      n.PageCount,
+		GoogleBooksID:  n.GoogleBooksID,
+		SeriesName:     n.SeriesName,
+		SeriesPosition: n.SeriesPosition,
+		Priority:       n.Priority,
+		Note:           n.Note,
+		Source:         n.Source,
+	}, ve)
+
+	return ve
+}
+
+func (u UpdateWishParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateWish(u, ve)
+	return ve
+}
+
+// ValidateWish validates the wish fields, ISBN is optional until the book is
+// bought
+func ValidateWish(w UpdateWishParams, ve ValidationErrors) {
+	if len(w.Isbn) > 0 {
+		ValidateStringNumeric(w.Isbn, "isbn", "ISBN", ve)
+		ValidateISBN13(w.Isbn, "isbn", "ISBN", ve)
+	}
+
+	ValidateStringPresent(w.Title, "title", "Title", ve)
+	ValidateStringLength(w.Title, "title", "Title", ve, 0, 100)
+	ValidateStringLength(w.Subtitle, "subtitle", "Subtitle", ve, 0, 100)
+	ValidateStringLength(w.Author, "author", "Author", ve, 0, 500)
+	ValidateAuthors(w.Author, "author", "Author", ve)
+	ValidateStringLength(w.Description, "description", "Description", ve, 0, 5000)
+	ValidateStringLength(w.Publisher, "publisher", "Publisher", ve, 0, 50)
+	ValidateStringLength(w.GoogleBooksID.String, "google_books_id", "Google Books ID", ve, 0, 30)
+	SeriesForm{Name: w.SeriesName, Position: w.SeriesPosition}.Validate(ve)
+
+	if w.Priority < 0 || int(w.Priority) >= len(WISH_PRIORITIES) {
+		ve.Add("priority", fmt.Errorf("Priority has to be one of %s", strings.Join(WISH_PRIORITIES, ", ")))
+	}
+
+	ValidateStringLength(w.Note, "note", "Note", ve, 0, 1000)
+	ValidateStringLength(w.Source, "source", "Heard about it from", ve, 0, 200)
+}
+
+func (n NewNoteParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateNote(UpdateNoteParams{
+		HighlightID: n.HighlightID,
+		PageFrom:    n.PageFrom,
+		PageTo:      n.PageTo,
+		Content:     n.Content,
+	}, ve)
+
+	return ve
+}
+
+func (u UpdateNoteParams) Validate() ValidationErrors {
+	ve := ValidationErrors{}
+	ValidateNote(u, ve)
+	return ve
+}
+
+// ValidateNote validates the note fields, the page range is optional and can
+// be a single page
+func ValidateNote(n UpdateNoteParams, ve ValidationErrors) {
+	ValidateStringPresent(n.Content, "content", "Content", ve)
+	ValidateStringLength(n.Content, "content", "Content", ve, 0, 10000)
+
+	if n.PageFrom.Valid {
+		ValidateInt32Min(n.PageFrom.Int32, "page_from", "First page", ve, 0)
+	}
+
+	if n.PageTo.Valid && !n.PageFrom.Valid {
+		ve.Add("page_from", fmt.Errorf("First page can't be empty when the last page is set"))
+	}
+
+	if n.PageFrom.Valid && n.PageTo.Valid && n.PageTo.Int32 < n.PageFrom.Int32 {
+		ve.Add("page_to", fmt.Errorf("Last page shouldn't be before the first page"))
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// WISH_PRIORITIES are the wish priority labels indexed by the priority value
+var WISH_PRIORITIES = []string{"Low", "Normal", "High"}
+
+// RESERVATION_TOKEN is the session key of the random token that identifies the
+// visitor who reserved a wish, so anonymous visitors can cancel only their own
+// reservations
+const RESERVATION_TOKEN = "reservation_token"
+
+func ReservationToken(r Request) string {
+	token, _ := SESSION(r).Values[RESERVATION_TOKEN].(string)
+	return token
+}
+
+// NewReservationToken returns the visitor reservation token, a new one is saved
+// in the session the first time
+func NewReservationToken(w Response, r Request) (string, error) {
+	if token := ReservationToken(r); len(token) > 0 {
+		return token, nil
+	}
+
+	s := SESSION(r)
+	token := uuid.New().String()
+	s.Values[RESERVATION_TOKEN] = token
+	return token, s.Save(r, w)
+}
+
+// ReservedByVisitor tells if the logged in user or the visitor holding the
+// token reserved the wish
+func ReservedByVisitor(wish Wish, who *User, token string) bool {
+	if who != nil && wish.ReserverID.Valid && wish.ReserverID.Int64 == who.ID {
+		return true
+	}
+
+	return len(token) > 0 && wish.ReserverToken.Valid &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(wish.ReserverToken.String)) == 1
+}
+
+// BuyWish converts a wish to a book with one copy on the shelf then deletes the
+// wish. when the user already has a book with the same ISBN a copy is added to
+// it instead.
+func BuyWish(ctx context.Context, q *Queries, wish Wish, params NewBookParams, shelfID sql.NullInt64) error {
+	book, err := q.BookByIsbnAndUser(ctx, BookByIsbnAndUserParams{
+		UserID: wish.UserID,
+		Isbn:   params.Isbn,
+	})
+
+	bookID := book.ID
+	if err == sql.ErrNoRows {
+		work, err := q.NewWork(ctx, NewWorkParams{
+			UserID: wish.UserID,
+			Title:  params.Title,
+		})
+		if err != nil {
+			return err
+		}
+
+		params.WorkID = work.ID
+		newBook, err := q.NewBook(ctx, params)
+		if err != nil {
+			return err
+		}
+		bookID = newBook.ID
+
+		if err = SetBookAuthors(ctx, q, wish.UserID, bookID, params.Author); err != nil {
+			return err
+		}
+
+		series := SeriesForm{Name: wish.SeriesName, Position: wish.SeriesPosition}
+		if err = SetWorkSeries(ctx, q, wish.UserID, work.ID, series); err != nil {
+			return err
+		}
+
+		if err = AuditNewBook(ctx, q, wish.UserID, bookID); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if _, err = q.NewCopy(ctx, NewCopyParams{BookID: bookID, ShelfID: shelfID}); err != nil {
+		return err
+	}
+
+	return q.DeleteWish(ctx, wish.ID)
+}